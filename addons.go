@@ -1,15 +1,27 @@
 package tukui
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 )
 
+// ErrEmptyResponse is returned if the API has no data for a query, e.g. for non existing IDs.
+var ErrEmptyResponse = errors.New("empty response")
+
+// ErrUnknownField is wrapped by the Warnings of a strict Client about fields the
+// Addon type does not know.
+var ErrUnknownField = errors.New("unknown field")
+
 // An Addon is the basic return of the HTTP call. It contains the fields specified by
-// the API. Pointers to strings can be nil.
+// the API. Pointers to strings can be nil. Every field is decoded as a Scalar, so
+// numbers or booleans where the API usually sends strings are kept as text.
 type Addon struct {
 	// ID number of addon
 	Id *string `json:"id,omitempty"`
@@ -39,17 +51,25 @@ type Addon struct {
 	LastDownload *string `json:"last_download,omitempty"`
 	// a donate url if the addon author accept donations
 	DonateUrl *string `json:"donate_url,omitempty"`
-}
-
-type uiAddon struct {
-	Addon
-
-	// id is an integer not a string for UIs
-	Id *json.Number `json:"id,omitempty"`
-	// downloads is an integer not a string for UIs
-	Downloads *json.Number `json:"downloads,omitempty"`
-	// last download has a different name for UIs
-	LastDownload *string `json:"lastdownload,omitempty"`
+	// the name of the addon in the URLs of the v1 API, e.g. elvui
+	Slug *string `json:"slug,omitempty"`
+	// url of the changelog of the addon
+	ChangelogUrl *string `json:"changelog_url,omitempty"`
+	// url to report issues of the addon
+	TicketUrl *string `json:"ticket_url,omitempty"`
+	// url of the source code repository of the addon
+	GitUrl *string `json:"git_url,omitempty"`
+	// url of the logo of the addon
+	LogoUrl *string `json:"logo_url,omitempty"`
+	// the folders the addon installs into Interface/AddOns
+	Directories []string `json:"directories,omitempty"`
+	// the patches of all game clients the addon is compatible with, Patch holds the
+	// one of the queried flavor
+	Patches []string `json:"patches,omitempty"`
+
+	// Extra holds the fields of the API that are not known to this package, like
+	// gallery_url, keyed by their name. It is nil if there are none.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // AddonClient is a set of functions that can be queried from the TukUI.org API
@@ -63,6 +83,14 @@ type AddonClient interface {
 	GetTukUI() (Addon, *http.Response, error)
 	// GetElvUI returns the Addon for the main ElvUI
 	GetElvUI() (Addon, *http.Response, error)
+	// GetAddonBySlug returns the Addon with the given slug, e.g. elvui or addonskins.
+	// APIs without slugs derive them from the catalog, see FindBySlug.
+	GetAddonBySlug(ctx context.Context, slug string) (Addon, *http.Response, error)
+	// GetUI returns the UI suite with the given name, e.g. elvui. For suites that do
+	// not exist for the flavor the function returns ErrUnknownUI.
+	GetUI(ctx context.Context, name string) (Addon, *http.Response, error)
+	// ListUIs returns all UI suites available for the flavor.
+	ListUIs(ctx context.Context) ([]Addon, *http.Response, error)
 }
 
 type retailClient struct {
@@ -94,63 +122,159 @@ func newClassicClient(client *Client) *classicClient {
 }
 
 func (r *retailClient) GetTukUI() (Addon, *http.Response, error) {
-	var tukui uiAddon
+	return r.getUI(context.Background(), "tukui")
+}
+
+func (r *retailClient) GetElvUI() (Addon, *http.Response, error) {
+	return r.getUI(context.Background(), "elvui")
+}
+
+func (r *retailClient) getUI(ctx context.Context, name string) (Addon, *http.Response, error) {
+	var ui Addon
 
-	resp, err := r.queryAPI("ui", "tukui", &tukui)
+	resp, err := r.queryAPI(ctx, "ui", name, &ui)
 
-	return convertAddon(tukui), resp, err
+	return ui, resp, err
 }
 
-func (r *retailClient) GetElvUI() (Addon, *http.Response, error) {
-	var elvui uiAddon
+// GetUI uses the ui query and falls back to the UI suites of the catalog.
+func (r *retailClient) GetUI(ctx context.Context, name string) (Addon, *http.Response, error) {
+	ui, resp, err := r.getUI(ctx, strings.ToLower(strings.TrimSpace(name)))
+	if !errors.Is(err, ErrEmptyResponse) {
+		return ui, resp, err
+	}
+
+	addons, resp, err := r.queryAddons(ctx, "addons", "all")
+	if err != nil {
+		return Addon{}, resp, err
+	}
+
+	ui, err = findUI(addons, name, FlavorRetail)
 
-	resp, err := r.queryAPI("ui", "elvui", &elvui)
+	return ui, resp, err
+}
+
+// ListUIs returns the UI suites of the catalog and the UISuites of the ui query.
+func (r *retailClient) ListUIs(ctx context.Context) ([]Addon, *http.Response, error) {
+	addons, resp, err := r.queryAddons(ctx, "addons", "all")
+	if err != nil {
+		return nil, resp, err
+	}
+
+	uis, err := r.addUISuites(ctx, filterUIs(addons))
+
+	return uis, resp, err
+}
+
+// addUISuites appends the UISuites of the ui query that are not in uis yet.
+func (r *retailClient) addUISuites(ctx context.Context, uis []Addon) ([]Addon, error) {
+	seen := make(map[string]bool)
+	for _, ui := range uis {
+		seen[stringValue(ui.Id)] = true
+	}
+
+	for _, name := range UISuites {
+		ui, _, err := r.getUI(ctx, name)
+		if errors.Is(err, ErrEmptyResponse) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[stringValue(ui.Id)] {
+			uis = append(uis, ui)
+		}
+	}
 
-	return convertAddon(elvui), resp, err
+	return uis, nil
 }
 
 func (r *retailClient) GetAddon(id int) (Addon, *http.Response, error) {
 	var addon Addon
 
-	resp, err := r.queryAPI("addon", strconv.Itoa(id), &addon)
+	resp, err := r.queryAPI(context.Background(), "addon", strconv.Itoa(id), &addon)
 
 	return addon, resp, err
 }
 
 func (r *retailClient) GetAddons() ([]Addon, *http.Response, error) {
-	var addons []Addon
+	return r.queryAddons(context.Background(), "addons", "all")
+}
 
-	resp, err := r.queryAPI("addons", "all", &addons)
+// GetAddonBySlug uses the ui query for the TukUI and ElvUI suites, which are not
+// part of the retail catalog, and FindBySlug for all other addons.
+func (r *retailClient) GetAddonBySlug(ctx context.Context, slug string) (Addon, *http.Response, error) {
+	switch name := normalizeName(slug); name {
+	case "tukui", "elvui":
+		return r.getUI(ctx, name)
+	}
 
-	return addons, resp, err
+	return r.findBySlug(ctx, "addons", slug)
 }
 
 func (c *classicClient) GetTukUI() (Addon, *http.Response, error) {
-	return c.GetAddon(1)
+	return c.GetUI(context.Background(), "tukui")
 }
 
 func (c *classicClient) GetElvUI() (Addon, *http.Response, error) {
-	return c.GetAddon(2)
+	return c.GetUI(context.Background(), "elvui")
+}
+
+// GetUI looks up the UI suite by its name in the classic catalog, the IDs of the
+// suites are not fixed.
+func (c *classicClient) GetUI(ctx context.Context, name string) (Addon, *http.Response, error) {
+	addons, resp, err := c.queryAddons(ctx, "classic-addons", "all")
+	if err != nil {
+		return Addon{}, resp, err
+	}
+
+	ui, err := findUI(addons, name, FlavorClassic)
+
+	return ui, resp, err
+}
+
+// ListUIs returns the UI suites of the classic catalog.
+func (c *classicClient) ListUIs(ctx context.Context) ([]Addon, *http.Response, error) {
+	addons, resp, err := c.queryAddons(ctx, "classic-addons", "all")
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return filterUIs(addons), resp, nil
 }
 
 func (c *classicClient) GetAddon(id int) (Addon, *http.Response, error) {
 	var addon Addon
 
-	resp, err := c.queryAPI("classic-addon", strconv.Itoa(id), &addon)
+	resp, err := c.queryAPI(context.Background(), "classic-addon", strconv.Itoa(id), &addon)
 
 	return addon, resp, err
 }
 
 func (c *classicClient) GetAddons() ([]Addon, *http.Response, error) {
-	var addons []Addon
+	return c.queryAddons(context.Background(), "classic-addons", "all")
+}
+
+// GetAddonBySlug looks up the addon with FindBySlug, the classic catalog includes
+// the TukUI and ElvUI suites.
+func (c *classicClient) GetAddonBySlug(ctx context.Context, slug string) (Addon, *http.Response, error) {
+	return c.findBySlug(ctx, "classic-addons", slug)
+}
+
+func (a *apiClient) findBySlug(ctx context.Context, key, slug string) (Addon, *http.Response, error) {
+	addons, resp, err := a.queryAddons(ctx, key, "all")
+	if err != nil {
+		return Addon{}, resp, err
+	}
 
-	resp, err := c.queryAPI("classic-addons", "all", &addons)
+	addon, err := FindBySlug(addons, slug)
 
-	return addons, resp, err
+	return addon, resp, err
 }
 
-func (a *apiClient) queryAPI(key, value string, data interface{}) (*http.Response, error) {
-	req, err := http.NewRequest(http.MethodGet, a.client.url, nil)
+func (a *apiClient) queryAPI(ctx context.Context, key, value string, data interface{}) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.client.url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -159,7 +283,25 @@ func (a *apiClient) queryAPI(key, value string, data interface{}) (*http.Respons
 	query.Add(key, value)
 	req.URL.RawQuery = query.Encode()
 
-	resp, err := a.client.httpClient.Do(req)
+	return a.client.getJSON(req, key+"="+value, data)
+}
+
+// queryAddons queries a list of addons.
+func (a *apiClient) queryAddons(ctx context.Context, key, value string) ([]Addon, *http.Response, error) {
+	var records []json.RawMessage
+
+	resp, err := a.queryAPI(ctx, key, value, &records)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return a.client.decodeAddons(key+"="+value, records), resp, nil
+}
+
+// getJSON sends the request and decodes the JSON response into data. The query
+// names the response in Warnings.
+func (c *Client) getJSON(req *http.Request, query string, data interface{}) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return resp, err
 	}
@@ -167,7 +309,7 @@ func (a *apiClient) queryAPI(key, value string, data interface{}) (*http.Respons
 	defer resp.Body.Close()
 
 	if resp.ContentLength == 0 {
-		return resp, errors.New("empty response")
+		return resp, ErrEmptyResponse
 	}
 
 	body, err := ioutil.ReadAll(resp.Body)
@@ -175,34 +317,56 @@ func (a *apiClient) queryAPI(key, value string, data interface{}) (*http.Respons
 		return resp, err
 	}
 
-	return resp, json.Unmarshal(body, data)
+	if err := json.Unmarshal(body, data); err != nil {
+		return resp, err
+	}
+
+	if addon, ok := data.(*Addon); ok {
+		c.reportUnknownFields(query, -1, *addon, make(map[string]bool))
+	}
+
+	return resp, nil
 }
 
-func convertAddon(ui uiAddon) Addon {
-	addon := Addon{
-		Name:          ui.Name,
-		SmallDesc:     ui.SmallDesc,
-		Author:        ui.Author,
-		Version:       ui.Version,
-		ScreenshotUrl: ui.ScreenshotUrl,
-		URL:           ui.URL,
-		Category:      ui.Category,
-		LastUpdate:    ui.LastUpdate,
-		Patch:         ui.Patch,
-		WebUrl:        ui.WebUrl,
-		LastDownload:  ui.LastDownload,
-		DonateUrl:     ui.DonateUrl,
+// decodeAddons decodes the records of a list. Records that cannot be decoded are
+// skipped with a Warning instead of failing the whole list.
+func (c *Client) decodeAddons(query string, records []json.RawMessage) []Addon {
+	addons := make([]Addon, 0, len(records))
+	seen := make(map[string]bool)
+	for i, record := range records {
+		var addon Addon
+		if err := json.Unmarshal(record, &addon); err != nil {
+			c.warning(Warning{Query: query, Index: i, Err: err})
+			continue
+		}
+
+		c.reportUnknownFields(query, i, addon, seen)
+		addons = append(addons, addon)
 	}
 
-	if ui.Id != nil {
-		id := ui.Id.String()
-		addon.Id = &id
+	return addons
+}
+
+// reportUnknownFields warns about the Extra fields of the addon at the index of the
+// response if the Client is strict. A negative index reports for a single record.
+// Each field is reported once per response, the first time it is added to seen.
+func (c *Client) reportUnknownFields(query string, index int, addon Addon, seen map[string]bool) {
+	if !c.strict {
+		return
 	}
 
-	if ui.Downloads != nil {
-		downloads := ui.Downloads.String()
-		addon.Downloads = &downloads
+	keys := make([]string, 0, len(addon.Extra))
+	for key := range addon.Extra {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 
-	return addon
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		c.warning(Warning{Query: query, Index: index, Err: fmt.Errorf("%w %q", ErrUnknownField, key)})
+	}
 }
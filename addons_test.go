@@ -1,7 +1,9 @@
 package tukui
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -302,6 +304,9 @@ func TestRetail_GetAddons(t *testing.T) {
 			Patch:         String("8.3"),
 			LastDownload:  String("2020-09-04 09:32:17"),
 			WebUrl:        String("https://www.tukui.org/addons.php?id=6"),
+			Extra: map[string]json.RawMessage{
+				"changelog": json.RawMessage(`"https://www.tukui.org/addons.php?id=6&changelog"`),
+			},
 		},
 	}
 
@@ -314,6 +319,11 @@ func TestRetail_GetAddons_Invalid(t *testing.T) {
 	client, mux, teardown := setupTestEnv()
 	defer teardown()
 
+	var warnings []Warning
+	client.SetWarningHandler(func(w Warning) {
+		warnings = append(warnings, w)
+	})
+
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		testHTTPMethod(t, r, http.MethodGet)
 		testHTTPQuery(t, r, url.Values(map[string][]string{
@@ -324,15 +334,34 @@ func TestRetail_GetAddons_Invalid(t *testing.T) {
 		fmt.Fprint(w,
 			`[
 				{
-					"Id":0
+					"id": {"value": 0}
+				},
+				{
+					"id": "3",
+					"name": "AddOnSkins"
 				}
 			]`,
 		)
 	})
 
-	_, _, err := client.RetailAddons.GetAddons()
-	if err == nil {
-		t.Errorf("RetailAddons.GetAddons() returned no error")
+	addons, _, err := client.RetailAddons.GetAddons()
+	if err != nil {
+		t.Fatalf("RetailAddons.GetAddons() returned error: %v", err)
+	}
+
+	want := []Addon{
+		{
+			Id:   String("3"),
+			Name: String("AddOnSkins"),
+		},
+	}
+
+	if !cmp.Equal(addons, want) {
+		t.Errorf("RetailAddons.GetAddons() returned %+v, want %+v", addons, want)
+	}
+
+	if len(warnings) != 1 || warnings[0].Query != "addons=all" || warnings[0].Index != 0 {
+		t.Errorf("RetailAddons.GetAddons() warned %+v, want one warning for record 0", warnings)
 	}
 }
 
@@ -439,6 +468,11 @@ func TestRetail_GetTukUI(t *testing.T) {
 		ScreenshotUrl: String("https://www.tukui.org/images/screenshots/t4.jpg"),
 		Downloads:     String("2147483000"),
 		Category:      String("Full UI Replacements"),
+		Extra: map[string]json.RawMessage{
+			"changelog": json.RawMessage(`"https://www.tukui.org/ui/tukui/changelog"`),
+			"ticket":    json.RawMessage(`"https://git.tukui.org/Tukz/Tukui/issues"`),
+			"git":       json.RawMessage(`"https://git.tukui.org/Tukz/Tukui"`),
+		},
 	}
 
 	if !cmp.Equal(tukui, want) {
@@ -459,7 +493,7 @@ func TestRetail_GetTukUI_Invalid(t *testing.T) {
 		}))
 		fmt.Fprint(w,
 			`{
-				"patch": 1.2
+				"patch": {"major": 1, "minor": 2}
 			}`,
 		)
 	})
@@ -544,6 +578,11 @@ func TestRetail_GetElvUI(t *testing.T) {
 		ScreenshotUrl: String("https://www.tukui.org/images/screenshots/DarkTheme_ThickBorders_DPS.jpg"),
 		Downloads:     String("2147483000"),
 		Category:      String("Full UI Replacements"),
+		Extra: map[string]json.RawMessage{
+			"changelog": json.RawMessage(`"https://www.tukui.org/ui/elvui/changelog"`),
+			"ticket":    json.RawMessage(`"https://git.tukui.org/elvui/elvui/issues"`),
+			"git":       json.RawMessage(`"https://git.tukui.org/elvui/elvui"`),
+		},
 	}
 
 	if !cmp.Equal(elvui, want) {
@@ -564,7 +603,7 @@ func TestRetail_GetElvUI_Invalid(t *testing.T) {
 		}))
 		fmt.Fprint(w,
 			`{
-				"patch": 1.2
+				"patch": {"major": 1, "minor": 2}
 			}`,
 		)
 	})
@@ -679,6 +718,9 @@ func TestClassic_GetAddons(t *testing.T) {
 			Patch:         String("8.3"),
 			LastDownload:  String("2020-09-04 09:32:17"),
 			WebUrl:        String("https://www.tukui.org/classic-addons.php?id=6"),
+			Extra: map[string]json.RawMessage{
+				"changelog": json.RawMessage(`"https://www.tukui.org/classic-addons.php?id=6&changelog"`),
+			},
 		},
 	}
 
@@ -691,6 +733,11 @@ func TestClassic_GetAddons_Invalid(t *testing.T) {
 	client, mux, teardown := setupTestEnv()
 	defer teardown()
 
+	var warnings []Warning
+	client.SetWarningHandler(func(w Warning) {
+		warnings = append(warnings, w)
+	})
+
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		testHTTPMethod(t, r, http.MethodGet)
 		testHTTPQuery(t, r, url.Values(map[string][]string{
@@ -701,15 +748,34 @@ func TestClassic_GetAddons_Invalid(t *testing.T) {
 		fmt.Fprint(w,
 			`[
 				{
-					"Id":0
+					"id": {"value": 0}
+				},
+				{
+					"id": "3",
+					"name": "AddOnSkins"
 				}
 			]`,
 		)
 	})
 
-	_, _, err := client.ClassicAddons.GetAddons()
-	if err == nil {
-		t.Errorf("ClassicAddons.GetAddons() returned no error")
+	addons, _, err := client.ClassicAddons.GetAddons()
+	if err != nil {
+		t.Fatalf("ClassicAddons.GetAddons() returned error: %v", err)
+	}
+
+	want := []Addon{
+		{
+			Id:   String("3"),
+			Name: String("AddOnSkins"),
+		},
+	}
+
+	if !cmp.Equal(addons, want) {
+		t.Errorf("ClassicAddons.GetAddons() returned %+v, want %+v", addons, want)
+	}
+
+	if len(warnings) != 1 || warnings[0].Query != "classic-addons=all" || warnings[0].Index != 0 {
+		t.Errorf("ClassicAddons.GetAddons() warned %+v, want one warning for record 0", warnings)
 	}
 }
 
@@ -769,12 +835,12 @@ func TestClassic_GetTukUI(t *testing.T) {
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		testHTTPMethod(t, r, http.MethodGet)
 		testHTTPQuery(t, r, url.Values(map[string][]string{
-			"classic-addon": {
-				"1",
+			"classic-addons": {
+				"all",
 			},
 		}))
 		fmt.Fprint(w,
-			`{
+			`[{
 				"id": "1",
 				"name": "Tukui",
 				"small_desc": "A clean, lightweight, minimalist and popular user interface among the warcraft community since 2007.",
@@ -790,7 +856,7 @@ func TestClassic_GetTukUI(t *testing.T) {
 				"web_url": "https://www.tukui.org/classic-addons.php?id=1",
 				"changelog": "https://www.tukui.org/classic-addons.php?id=1&changelog",
 				"donate_url": "https://www.tukui.org/support.php"
-			}`,
+			}]`,
 		)
 	})
 
@@ -814,6 +880,9 @@ func TestClassic_GetTukUI(t *testing.T) {
 		ScreenshotUrl: String("https://www.tukui.org/1"),
 		Downloads:     String("260226"),
 		Category:      String("Interfaces"),
+		Extra: map[string]json.RawMessage{
+			"changelog": json.RawMessage(`"https://www.tukui.org/classic-addons.php?id=1&changelog"`),
+		},
 	}
 
 	if !cmp.Equal(tukui, want) {
@@ -821,19 +890,19 @@ func TestClassic_GetTukUI(t *testing.T) {
 	}
 }
 
-func TestClassic_GetTukUI_Invalid(t *testing.T) {
+func TestClassic_GetTukUI_NumericID(t *testing.T) {
 	client, mux, teardown := setupTestEnv()
 	defer teardown()
 
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		testHTTPMethod(t, r, http.MethodGet)
 		testHTTPQuery(t, r, url.Values(map[string][]string{
-			"classic-addon": {
-				"1",
+			"classic-addons": {
+				"all",
 			},
 		}))
 		fmt.Fprint(w,
-			`{
+			`[{
 				"id": 1,
 				"name": "Tukui",
 				"small_desc": "A clean, lightweight, minimalist and popular user interface among the warcraft community since 2007.",
@@ -849,13 +918,17 @@ func TestClassic_GetTukUI_Invalid(t *testing.T) {
 				"web_url": "https://www.tukui.org/classic-addons.php?id=1",
 				"changelog": "https://www.tukui.org/classic-addons.php?id=1&changelog",
 				"donate_url": "https://www.tukui.org/support.php"
-			}`,
+			}]`,
 		)
 	})
 
-	_, _, err := client.ClassicAddons.GetTukUI()
-	if err == nil {
-		t.Errorf("ClassicAddons.GetTukUI() returned no error")
+	addon, _, err := client.ClassicAddons.GetTukUI()
+	if err != nil {
+		t.Fatalf("ClassicAddons.GetTukUI() returned error: %v", err)
+	}
+
+	if addon.Id == nil || *addon.Id != "1" {
+		t.Errorf("ClassicAddons.GetTukUI() returned id %v, want %q", addon.Id, "1")
 	}
 }
 
@@ -866,8 +939,8 @@ func TestClassic_GetTukUI_NoContent(t *testing.T) {
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		testHTTPMethod(t, r, http.MethodGet)
 		testHTTPQuery(t, r, url.Values(map[string][]string{
-			"classic-addon": {
-				"1",
+			"classic-addons": {
+				"all",
 			},
 		}))
 	})
@@ -886,12 +959,12 @@ func TestClassic_GetElvUI(t *testing.T) {
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		testHTTPMethod(t, r, http.MethodGet)
 		testHTTPQuery(t, r, url.Values(map[string][]string{
-			"classic-addon": {
-				"2",
+			"classic-addons": {
+				"all",
 			},
 		}))
 		fmt.Fprint(w,
-			`{
+			`[{
 				"id": "2",
 				"name": "ElvUI",
 				"small_desc": "A USER INTERFACE DESIGNED AROUND USER-FRIENDLINESS WITH EXTRA FEATURES THAT ARE NOT INCLUDED IN THE STANDARD UI.\r\n",
@@ -906,7 +979,7 @@ func TestClassic_GetElvUI(t *testing.T) {
 				"last_download": "2020-09-21 13:58:12",
 				"web_url": "https://www.tukui.org/classic-addons.php?id=2",
 				"changelog": "https://www.tukui.org/classic-addons.php?id=2&changelog"
-			}`,
+			}]`,
 		)
 	})
 
@@ -929,6 +1002,9 @@ func TestClassic_GetElvUI(t *testing.T) {
 		ScreenshotUrl: String("https://www.tukui.org/2"),
 		Downloads:     String("1721530"),
 		Category:      String("Interfaces"),
+		Extra: map[string]json.RawMessage{
+			"changelog": json.RawMessage(`"https://www.tukui.org/classic-addons.php?id=2&changelog"`),
+		},
 	}
 
 	if !cmp.Equal(elvui, want) {
@@ -936,19 +1012,19 @@ func TestClassic_GetElvUI(t *testing.T) {
 	}
 }
 
-func TestClassic_GetElvUI_Invalid(t *testing.T) {
+func TestClassic_GetElvUI_NumericID(t *testing.T) {
 	client, mux, teardown := setupTestEnv()
 	defer teardown()
 
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		testHTTPMethod(t, r, http.MethodGet)
 		testHTTPQuery(t, r, url.Values(map[string][]string{
-			"classic-addon": {
-				"2",
+			"classic-addons": {
+				"all",
 			},
 		}))
 		fmt.Fprint(w,
-			`{
+			`[{
 				"id": 2,
 				"name": "ElvUI",
 				"small_desc": "A USER INTERFACE DESIGNED AROUND USER-FRIENDLINESS WITH EXTRA FEATURES THAT ARE NOT INCLUDED IN THE STANDARD UI.\r\n",
@@ -963,13 +1039,17 @@ func TestClassic_GetElvUI_Invalid(t *testing.T) {
 				"last_download": "2020-09-21 13:58:12",
 				"web_url": "https://www.tukui.org/classic-addons.php?id=2",
 				"changelog": "https://www.tukui.org/classic-addons.php?id=2&changelog"
-			}`,
+			}]`,
 		)
 	})
 
-	_, _, err := client.ClassicAddons.GetElvUI()
-	if err == nil {
-		t.Errorf("ClassicAddons.GetElvUI() returned no error")
+	addon, _, err := client.ClassicAddons.GetElvUI()
+	if err != nil {
+		t.Fatalf("ClassicAddons.GetElvUI() returned error: %v", err)
+	}
+
+	if addon.Id == nil || *addon.Id != "2" {
+		t.Errorf("ClassicAddons.GetElvUI() returned id %v, want %q", addon.Id, "2")
 	}
 }
 
@@ -980,8 +1060,8 @@ func TestClassic_GetElvUI_NoContent(t *testing.T) {
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		testHTTPMethod(t, r, http.MethodGet)
 		testHTTPQuery(t, r, url.Values(map[string][]string{
-			"classic-addon": {
-				"2",
+			"classic-addons": {
+				"all",
 			},
 		}))
 	})
@@ -993,95 +1073,167 @@ func TestClassic_GetElvUI_NoContent(t *testing.T) {
 	}
 }
 
-func TestConvertAddon_NoID_NoDownloads_NoLastDownload(t *testing.T) {
-	ui := uiAddon{
-		Addon: Addon{
-			Author:        String("Elv"),
-			URL:           String("https://www.tukui.org/classic-addons.php?download=2"),
-			Version:       String("1.31"),
-			Name:          String("ElvUI"),
-			Patch:         String("1.13.5"),
-			LastUpdate:    String("2020-09-07 19:42:13"),
-			WebUrl:        String("https://www.tukui.org/classic-addons.php?id=2"),
-			SmallDesc:     String("A USER INTERFACE DESIGNED AROUND USER-FRIENDLINESS WITH EXTRA FEATURES THAT ARE NOT INCLUDED IN THE STANDARD UI.\r\n"),
-			ScreenshotUrl: String("https://www.tukui.org/2"),
-			Category:      String("Interfaces"),
-		},
-		Id:           nil,
-		Downloads:    nil,
-		LastDownload: nil,
+func TestRetail_GetAddonBySlug(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testHTTPMethod(t, r, http.MethodGet)
+		switch {
+		case r.URL.Query().Get("ui") == "elvui":
+			fmt.Fprint(w, `{"id": -2, "name": "ElvUI"}`)
+		case r.URL.Query().Get("addons") == "all":
+			fmt.Fprint(w, `[{"id": "3", "name": "AddOnSkins"}, {"id": "6", "name": "Location Plus"}]`)
+		}
+	})
+
+	tests := []struct {
+		slug string
+		id   string
+	}{
+		{"elvui", "-2"},
+		{"ElvUI", "-2"},
+		{"location-plus", "6"},
 	}
 
-	got := convertAddon(ui)
+	for _, tt := range tests {
+		addon, _, err := client.RetailAddons.GetAddonBySlug(context.Background(), tt.slug)
+		if err != nil {
+			t.Errorf("RetailAddons.GetAddonBySlug(%q) returned error: %v", tt.slug, err)
+			continue
+		}
 
-	want := Addon{
-		Author:        String("Elv"),
-		URL:           String("https://www.tukui.org/classic-addons.php?download=2"),
-		Version:       String("1.31"),
-		Name:          String("ElvUI"),
-		Patch:         String("1.13.5"),
-		LastUpdate:    String("2020-09-07 19:42:13"),
-		WebUrl:        String("https://www.tukui.org/classic-addons.php?id=2"),
-		SmallDesc:     String("A USER INTERFACE DESIGNED AROUND USER-FRIENDLINESS WITH EXTRA FEATURES THAT ARE NOT INCLUDED IN THE STANDARD UI.\r\n"),
-		ScreenshotUrl: String("https://www.tukui.org/2"),
-		Category:      String("Interfaces"),
+		if *addon.Id != tt.id {
+			t.Errorf("RetailAddons.GetAddonBySlug(%q) returned id %s, want %s", tt.slug, *addon.Id, tt.id)
+		}
 	}
 
-	if !cmp.Equal(got, want) {
-		t.Errorf("convertAddon() returned %+v, want %+v", got, want)
+	if _, _, err := client.RetailAddons.GetAddonBySlug(context.Background(), "tukui"); !errors.Is(err, ErrEmptyResponse) {
+		t.Errorf("RetailAddons.GetAddonBySlug(%q) returned error %v, want %v", "tukui", err, ErrEmptyResponse)
 	}
 }
 
-func TestConvertAddon_EmptyID(t *testing.T) {
-	ui := uiAddon{
-		Addon: Addon{
-			Author:        String("Elv"),
-			URL:           String("https://www.tukui.org/classic-addons.php?download=2"),
-			Version:       String("1.31"),
-			Name:          String("ElvUI"),
-			Patch:         String("1.13.5"),
-			LastUpdate:    String("2020-09-07 19:42:13"),
-			WebUrl:        String("https://www.tukui.org/classic-addons.php?id=2"),
-			SmallDesc:     String("A USER INTERFACE DESIGNED AROUND USER-FRIENDLINESS WITH EXTRA FEATURES THAT ARE NOT INCLUDED IN THE STANDARD UI.\r\n"),
-			ScreenshotUrl: String("https://www.tukui.org/2"),
-			Category:      String("Interfaces"),
-		},
-		Id:           (*json.Number)(String("")),
-		Downloads:    (*json.Number)(String("123")),
-		LastDownload: String("2020-09-21 13:58:12"),
+func TestClassic_GetAddonBySlug(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testHTTPMethod(t, r, http.MethodGet)
+		testHTTPQuery(t, r, url.Values(map[string][]string{
+			"classic-addons": {
+				"all",
+			},
+		}))
+		fmt.Fprint(w, `[{"id": "2", "name": "ElvUI"}, {"id": "12", "name": "ElvUI"}]`)
+	})
+
+	if _, _, err := client.ClassicAddons.GetAddonBySlug(context.Background(), "elvui"); !errors.Is(err, ErrSlugCollision) {
+		t.Errorf("ClassicAddons.GetAddonBySlug() returned error %v, want %v", err, ErrSlugCollision)
 	}
 
-	got := convertAddon(ui)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
 
-	want := Addon{
-		Author:        String("Elv"),
-		URL:           String("https://www.tukui.org/classic-addons.php?download=2"),
-		Version:       String("1.31"),
-		Name:          String("ElvUI"),
-		Patch:         String("1.13.5"),
-		LastUpdate:    String("2020-09-07 19:42:13"),
-		WebUrl:        String("https://www.tukui.org/classic-addons.php?id=2"),
-		SmallDesc:     String("A USER INTERFACE DESIGNED AROUND USER-FRIENDLINESS WITH EXTRA FEATURES THAT ARE NOT INCLUDED IN THE STANDARD UI.\r\n"),
-		ScreenshotUrl: String("https://www.tukui.org/2"),
-		Category:      String("Interfaces"),
-		Id:            String(""),
-		Downloads:     String("123"),
-		LastDownload:  String("2020-09-21 13:58:12"),
+	if _, _, err := client.ClassicAddons.GetAddonBySlug(ctx, "elvui"); !errors.Is(err, context.Canceled) {
+		t.Errorf("ClassicAddons.GetAddonBySlug() with canceled context returned error %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestRetail_GetUI(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testHTTPMethod(t, r, http.MethodGet)
+		switch r.URL.RawQuery {
+		case "ui=elvui":
+			fmt.Fprint(w, `{"id": -2, "name": "ElvUI"}`)
+		case "addons=all":
+			fmt.Fprint(w, `[
+				{"id": "3", "name": "AddOnSkins", "category": "Skins"},
+				{"id": "9", "name": "Shadow & Light", "category": "Full UI Replacements"}
+			]`)
+		}
+	})
+
+	tests := []struct {
+		name string
+		id   string
+	}{
+		{"ElvUI", "-2"},
+		{"shadow-light", "9"},
+	}
+
+	for _, tt := range tests {
+		ui, _, err := client.RetailAddons.GetUI(context.Background(), tt.name)
+		if err != nil {
+			t.Errorf("RetailAddons.GetUI(%q) returned error: %v", tt.name, err)
+			continue
+		}
+
+		if *ui.Id != tt.id {
+			t.Errorf("RetailAddons.GetUI(%q) returned id %s, want %s", tt.name, *ui.Id, tt.id)
+		}
+	}
+
+	for _, name := range []string{"gw2", "AddOnSkins"} {
+		if _, _, err := client.RetailAddons.GetUI(context.Background(), name); !errors.Is(err, ErrUnknownUI) {
+			t.Errorf("RetailAddons.GetUI(%q) returned error %v, want %v", name, err, ErrUnknownUI)
+		}
+	}
+
+	uis, _, err := client.RetailAddons.ListUIs(context.Background())
+	if err != nil {
+		t.Fatalf("RetailAddons.ListUIs() returned error: %v", err)
+	}
+
+	want := []Addon{
+		{Id: String("9"), Name: String("Shadow & Light"), Category: String("Full UI Replacements")},
+		{Id: String("-2"), Name: String("ElvUI")},
 	}
 
-	if !cmp.Equal(got, want) {
-		t.Errorf("convertAddon() returned %+v, want %+v", got, want)
+	if !cmp.Equal(uis, want) {
+		t.Errorf("RetailAddons.ListUIs() returned %+v, want %+v", uis, want)
 	}
 }
 
-func TestConvertAddon_EmptyAddon(t *testing.T) {
-	ui := uiAddon{}
+func TestClassic_GetUI(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
 
-	got := convertAddon(ui)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		testHTTPMethod(t, r, http.MethodGet)
+		testHTTPQuery(t, r, url.Values(map[string][]string{
+			"classic-addons": {
+				"all",
+			},
+		}))
+		fmt.Fprint(w, `[
+			{"id": "3", "name": "AddOnSkins", "category": "Skins"},
+			{"id": "14", "name": "ElvUI", "category": "Interfaces"}
+		]`)
+	})
+
+	elvui, _, err := client.ClassicAddons.GetUI(context.Background(), "elvui")
+	if err != nil {
+		t.Fatalf("ClassicAddons.GetUI() returned error: %v", err)
+	}
+
+	if *elvui.Id != "14" {
+		t.Errorf("ClassicAddons.GetUI() returned id %s, want %s", *elvui.Id, "14")
+	}
+
+	_, _, err = client.ClassicAddons.GetTukUI()
+	if want := `unknown ui suite "tukui" for classic`; err == nil || err.Error() != want {
+		t.Errorf("ClassicAddons.GetTukUI() returned error %v, want %v", err, want)
+	}
 
-	want := Addon{}
+	uis, _, err := client.ClassicAddons.ListUIs(context.Background())
+	if err != nil {
+		t.Fatalf("ClassicAddons.ListUIs() returned error: %v", err)
+	}
 
-	if !cmp.Equal(got, want) {
-		t.Errorf("convertAddon() returned %+v, want %+v", got, want)
+	if want := []Addon{elvui}; !cmp.Equal(uis, want) {
+		t.Errorf("ClassicAddons.ListUIs() returned %+v, want %+v", uis, want)
 	}
 }
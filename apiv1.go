@@ -0,0 +1,280 @@
+package tukui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const v1BaseURL = "https://api.tukui.org/v1"
+
+// APIVersion selects the API of tukui.org a Client uses.
+type APIVersion int
+
+const (
+	// APILegacy is the api.php with numeric addon IDs and separate retail and
+	// classic catalogs. It is the default.
+	APILegacy APIVersion = iota
+	// APIV1 is the JSON API with slug based addons and one catalog for all
+	// flavors, which lists the compatible patches of every addon.
+	APIV1
+)
+
+func (v APIVersion) String() string {
+	switch v {
+	case APILegacy:
+		return "legacy"
+	case APIV1:
+		return "v1"
+	}
+
+	return "APIVersion(" + strconv.Itoa(int(v)) + ")"
+}
+
+// ParseAPIVersion returns the APIVersion with the given name, "legacy" or "v1".
+func ParseAPIVersion(name string) (APIVersion, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "legacy":
+		return APILegacy, nil
+	case "v1":
+		return APIV1, nil
+	}
+
+	return APILegacy, fmt.Errorf("unknown API version %q", name)
+}
+
+// SetAPIVersion switches the RetailAddons and ClassicAddons of the Client to the
+// given API.
+func (c *Client) SetAPIVersion(version APIVersion) error {
+	switch version {
+	case APILegacy:
+		c.RetailAddons = newRetailClient(c)
+		c.ClassicAddons = newClassicClient(c)
+	case APIV1:
+		c.RetailAddons = newV1Client(c, FlavorRetail)
+		c.ClassicAddons = newV1Client(c, FlavorClassic)
+	default:
+		return fmt.Errorf("unknown API version %d", version)
+	}
+
+	c.version = version
+
+	return nil
+}
+
+// APIVersion returns the API the Client uses.
+func (c *Client) APIVersion() APIVersion {
+	return c.version
+}
+
+// SetV1BaseURL changes the URL of the v1 API, e.g. to use a local test server.
+func (c *Client) SetV1BaseURL(url string) {
+	c.v1URL = strings.TrimSuffix(url, "/")
+}
+
+// DetectAPIVersion probes the v1 API and falls back to the api.php if it does not
+// answer with a list of addons. The detected version is set on the Client.
+func (c *Client) DetectAPIVersion(ctx context.Context) (APIVersion, error) {
+	if c.probe(ctx, c.v1URL+"/addons") == nil {
+		return APIV1, c.SetAPIVersion(APIV1)
+	}
+
+	if err := c.probe(ctx, c.url+"?addons=all"); err != nil {
+		return c.version, fmt.Errorf("detecting the API version: %w", err)
+	}
+
+	return APILegacy, c.SetAPIVersion(APILegacy)
+}
+
+// probe checks that the URL answers with a JSON array.
+func (c *Client) probe(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %s", url, resp.Status)
+	}
+
+	var records []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&records); err != nil {
+		return fmt.Errorf("%s: %w", url, err)
+	}
+
+	return nil
+}
+
+// PatchFor returns the patch of the game client of the given flavor the addon is
+// compatible with. It is looked up in Patches, or taken from Patch for addons
+// without a list of patches.
+func (a Addon) PatchFor(flavor Flavor) (string, bool) {
+	patches := a.Patches
+	if patches == nil && a.Patch != nil {
+		patches = []string{*a.Patch}
+	}
+
+	for _, patch := range patches {
+		iface, err := PatchToInterface(patch)
+		if err == nil && InterfaceFlavor(iface) == flavor {
+			return patch, true
+		}
+	}
+
+	return "", false
+}
+
+// v1Client queries the v1 API for the addons of one flavor.
+type v1Client struct {
+	client *Client
+	flavor Flavor
+}
+
+func newV1Client(client *Client, flavor Flavor) *v1Client {
+	return &v1Client{
+		client: client,
+		flavor: flavor,
+	}
+}
+
+func (v *v1Client) GetTukUI() (Addon, *http.Response, error) {
+	return v.GetUI(context.Background(), "tukui")
+}
+
+func (v *v1Client) GetElvUI() (Addon, *http.Response, error) {
+	return v.GetUI(context.Background(), "elvui")
+}
+
+// GetAddonBySlug queries the addon endpoint. Addons without a patch for the flavor
+// are not found, like in GetAddon.
+func (v *v1Client) GetAddonBySlug(ctx context.Context, slug string) (Addon, *http.Response, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if slug == "" {
+		return Addon{}, nil, fmt.Errorf("%w: invalid slug %q", ErrEmptyResponse, slug)
+	}
+
+	addon, resp, err := v.getSlug(ctx, slug)
+	if err != nil {
+		return Addon{}, resp, err
+	}
+
+	if len(addon.Patches) > 0 && addon.Patch == nil {
+		return Addon{}, resp, fmt.Errorf("%w: %s has no patch for %s", ErrEmptyResponse, slug, v.flavor)
+	}
+
+	return addon, resp, nil
+}
+
+// GetUI queries the addon endpoint with the name as slug.
+func (v *v1Client) GetUI(ctx context.Context, name string) (Addon, *http.Response, error) {
+	ui, resp, err := v.GetAddonBySlug(ctx, name)
+	if errors.Is(err, ErrEmptyResponse) || (err == nil && !IsUI(ui)) {
+		return Addon{}, resp, unknownUI(name, v.flavor)
+	}
+
+	return ui, resp, err
+}
+
+// ListUIs returns the UI suites of the catalog of the flavor.
+func (v *v1Client) ListUIs(ctx context.Context) ([]Addon, *http.Response, error) {
+	addons, resp, err := v.getAddons(ctx)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return filterUIs(addons), resp, nil
+}
+
+// GetAddon looks up the addon in the catalog, the v1 API has no query by ID.
+func (v *v1Client) GetAddon(id int) (Addon, *http.Response, error) {
+	addons, resp, err := v.GetAddons()
+	if err != nil {
+		return Addon{}, resp, err
+	}
+
+	want := strconv.Itoa(id)
+	for _, addon := range addons {
+		if addon.Id != nil && *addon.Id == want {
+			return addon, resp, nil
+		}
+	}
+
+	return Addon{}, resp, ErrEmptyResponse
+}
+
+// GetAddons returns the addons compatible with the flavor. Addons without any
+// patches are kept, as their compatibility is unknown.
+func (v *v1Client) GetAddons() ([]Addon, *http.Response, error) {
+	return v.getAddons(context.Background())
+}
+
+func (v *v1Client) getAddons(ctx context.Context) ([]Addon, *http.Response, error) {
+	var records []json.RawMessage
+
+	resp, err := v.query(ctx, "addons", &records)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	all := v.client.decodeAddons("addons", records)
+
+	addons := make([]Addon, 0, len(all))
+	for _, addon := range all {
+		if v.forFlavor(&addon) {
+			addons = append(addons, addon)
+		}
+	}
+
+	return addons, resp, nil
+}
+
+func (v *v1Client) getSlug(ctx context.Context, slug string) (Addon, *http.Response, error) {
+	var addon Addon
+
+	resp, err := v.query(ctx, "addon/"+url.PathEscape(slug), &addon)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return Addon{}, resp, fmt.Errorf("%w: no addon with slug %q", ErrEmptyResponse, slug)
+	}
+	if err != nil {
+		return addon, resp, err
+	}
+
+	v.forFlavor(&addon)
+
+	return addon, resp, nil
+}
+
+func (v *v1Client) query(ctx context.Context, path string, data interface{}) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.client.v1URL+"/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.client.getJSON(req, path, data)
+}
+
+// forFlavor sets the Patch of the addon to the one of the flavor and reports
+// whether the addon is compatible with the flavor.
+func (v *v1Client) forFlavor(addon *Addon) bool {
+	if len(addon.Patches) == 0 {
+		return true
+	}
+
+	patch, ok := addon.PatchFor(v.flavor)
+	if ok {
+		addon.Patch = &patch
+	}
+
+	return ok
+}
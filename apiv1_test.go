@@ -0,0 +1,340 @@
+package tukui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const v1Addons = `[
+	{
+		"id": -2,
+		"slug": "elvui",
+		"name": "ElvUI",
+		"version": "13.40",
+		"patch": ["10.1.5", "1.14.4", "3.4.2"],
+		"directories": ["ElvUI", "ElvUI_Options", "ElvUI_Libraries"]
+	},
+	{
+		"id": -1,
+		"slug": "tukui",
+		"name": "Tukui",
+		"version": "20.38",
+		"patch": ["10.1.5"]
+	},
+	{
+		"id": 3,
+		"slug": "addonskins",
+		"name": "AddOnSkins",
+		"version": "4.22"
+	}
+]`
+
+func setupV1TestEnv(t *testing.T) (*Client, *http.ServeMux) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/addons", func(w http.ResponseWriter, r *http.Request) {
+		testHTTPMethod(t, r, http.MethodGet)
+		fmt.Fprint(w, v1Addons)
+	})
+	mux.HandleFunc("/v1/addon/elvui", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": -2, "slug": "elvui", "name": "ElvUI", "version": "13.40", "patch": ["10.1.5", "1.14.4", "3.4.2"]}`)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := NewClient(nil)
+	client.SetBaseURL(server.URL + "/api.php")
+	client.SetV1BaseURL(server.URL + "/v1/")
+
+	if err := client.SetAPIVersion(APIV1); err != nil {
+		t.Fatal(err)
+	}
+
+	return client, mux
+}
+
+func TestV1_GetAddons(t *testing.T) {
+	client, _ := setupV1TestEnv(t)
+
+	wrath, err := client.AddonsFor(FlavorWrath)
+	if err != nil {
+		t.Fatalf("AddonsFor() returned error: %v", err)
+	}
+
+	tests := []struct {
+		addons AddonClient
+		want   []Addon
+	}{
+		{
+			client.RetailAddons,
+			[]Addon{
+				{
+					Id:          String("-2"),
+					Slug:        String("elvui"),
+					Name:        String("ElvUI"),
+					Version:     String("13.40"),
+					Patch:       String("10.1.5"),
+					Patches:     []string{"10.1.5", "1.14.4", "3.4.2"},
+					Directories: []string{"ElvUI", "ElvUI_Options", "ElvUI_Libraries"},
+				},
+				{
+					Id:      String("-1"),
+					Slug:    String("tukui"),
+					Name:    String("Tukui"),
+					Version: String("20.38"),
+					Patch:   String("10.1.5"),
+					Patches: []string{"10.1.5"},
+				},
+				{
+					Id:      String("3"),
+					Slug:    String("addonskins"),
+					Name:    String("AddOnSkins"),
+					Version: String("4.22"),
+				},
+			},
+		},
+		{
+			wrath,
+			[]Addon{
+				{
+					Id:          String("-2"),
+					Slug:        String("elvui"),
+					Name:        String("ElvUI"),
+					Version:     String("13.40"),
+					Patch:       String("3.4.2"),
+					Patches:     []string{"10.1.5", "1.14.4", "3.4.2"},
+					Directories: []string{"ElvUI", "ElvUI_Options", "ElvUI_Libraries"},
+				},
+				{
+					Id:      String("3"),
+					Slug:    String("addonskins"),
+					Name:    String("AddOnSkins"),
+					Version: String("4.22"),
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		addons, _, err := tt.addons.GetAddons()
+		if err != nil {
+			t.Fatalf("GetAddons() returned error: %v", err)
+		}
+
+		if !cmp.Equal(addons, tt.want) {
+			t.Errorf("GetAddons() returned %+v, want %+v", addons, tt.want)
+		}
+	}
+}
+
+func TestV1_GetAddon(t *testing.T) {
+	client, _ := setupV1TestEnv(t)
+
+	addon, _, err := client.ClassicAddons.GetAddon(3)
+	if err != nil {
+		t.Fatalf("ClassicAddons.GetAddon() returned error: %v", err)
+	}
+
+	if addon.Slug == nil || *addon.Slug != "addonskins" {
+		t.Errorf("ClassicAddons.GetAddon() returned %+v, want addonskins", addon)
+	}
+
+	// Tukui has no patch for Classic Era
+	if _, _, err := client.ClassicAddons.GetAddon(-1); !errors.Is(err, ErrEmptyResponse) {
+		t.Errorf("ClassicAddons.GetAddon(-1) returned error %v, want %v", err, ErrEmptyResponse)
+	}
+}
+
+func TestV1_GetElvUI(t *testing.T) {
+	client, mux := setupV1TestEnv(t)
+
+	elvui, _, err := client.ClassicAddons.GetElvUI()
+	if err != nil {
+		t.Fatalf("ClassicAddons.GetElvUI() returned error: %v", err)
+	}
+
+	want := Addon{
+		Id:      String("-2"),
+		Slug:    String("elvui"),
+		Name:    String("ElvUI"),
+		Version: String("13.40"),
+		Patch:   String("1.14.4"),
+		Patches: []string{"10.1.5", "1.14.4", "3.4.2"},
+	}
+
+	if !cmp.Equal(elvui, want) {
+		t.Errorf("ClassicAddons.GetElvUI() returned %+v, want %+v", elvui, want)
+	}
+
+	if _, _, err := client.RetailAddons.GetTukUI(); err == nil {
+		t.Errorf("RetailAddons.GetTukUI() without a response returned no error")
+	}
+
+	mux.HandleFunc("/v1/addon/tukui", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": -1, "slug": "tukui", "name": "Tukui", "version": "20.38", "patch": ["10.1.5"]}`)
+	})
+
+	if _, _, err := client.ClassicAddons.GetTukUI(); !errors.Is(err, ErrUnknownUI) {
+		t.Errorf("ClassicAddons.GetTukUI() of a retail suite returned error %v, want %v", err, ErrUnknownUI)
+	}
+}
+
+func TestV1_GetAddonBySlug(t *testing.T) {
+	client, mux := setupV1TestEnv(t)
+
+	var requested []string
+	mux.HandleFunc("/v1/addon/", func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		http.NotFound(w, r)
+	})
+
+	elvui, _, err := client.RetailAddons.GetAddonBySlug(context.Background(), "ElvUI")
+	if err != nil {
+		t.Fatalf("RetailAddons.GetAddonBySlug() returned error: %v", err)
+	}
+
+	if *elvui.Patch != "10.1.5" {
+		t.Errorf("RetailAddons.GetAddonBySlug() returned patch %s, want %s", *elvui.Patch, "10.1.5")
+	}
+
+	wrath, err := client.AddonsFor(FlavorCataclysm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := wrath.GetAddonBySlug(context.Background(), "elvui"); !errors.Is(err, ErrEmptyResponse) {
+		t.Errorf("GetAddonBySlug() for a flavor without patch returned error %v, want %v", err, ErrEmptyResponse)
+	}
+
+	if _, _, err := client.RetailAddons.GetAddonBySlug(context.Background(), "weakauras"); !errors.Is(err, ErrEmptyResponse) {
+		t.Errorf("GetAddonBySlug() of a missing addon returned error %v, want %v", err, ErrEmptyResponse)
+	}
+
+	if want := []string{"/v1/addon/weakauras"}; !cmp.Equal(requested, want) {
+		t.Errorf("GetAddonBySlug() requested %v, want %v", requested, want)
+	}
+}
+
+func TestV1_GetUI(t *testing.T) {
+	client, _ := setupV1TestEnv(t)
+
+	elvui, _, err := client.ClassicAddons.GetUI(context.Background(), "ElvUI")
+	if err != nil {
+		t.Fatalf("ClassicAddons.GetUI() returned error: %v", err)
+	}
+
+	if *elvui.Patch != "1.14.4" {
+		t.Errorf("ClassicAddons.GetUI() returned patch %s, want %s", *elvui.Patch, "1.14.4")
+	}
+
+	if _, _, err := client.ClassicAddons.GetUI(context.Background(), "tukui"); !errors.Is(err, ErrUnknownUI) {
+		t.Errorf("ClassicAddons.GetUI(%q) returned error %v, want %v", "tukui", err, ErrUnknownUI)
+	}
+
+	tests := []struct {
+		addons AddonClient
+		want   []string
+	}{
+		{client.RetailAddons, []string{"ElvUI", "Tukui"}},
+		{client.ClassicAddons, []string{"ElvUI"}},
+	}
+
+	for _, tt := range tests {
+		uis, _, err := tt.addons.ListUIs(context.Background())
+		if err != nil {
+			t.Fatalf("ListUIs() returned error: %v", err)
+		}
+
+		var names []string
+		for _, ui := range uis {
+			names = append(names, *ui.Name)
+		}
+
+		if !cmp.Equal(names, tt.want) {
+			t.Errorf("ListUIs() returned %v, want %v", names, tt.want)
+		}
+	}
+}
+
+func TestClient_DetectAPIVersion(t *testing.T) {
+	client, mux := setupV1TestEnv(t)
+	client.SetAPIVersion(APILegacy)
+
+	version, err := client.DetectAPIVersion(context.Background())
+	if err != nil || version != APIV1 || client.APIVersion() != APIV1 {
+		t.Errorf("DetectAPIVersion() returned %v, %v, want %v", version, err, APIV1)
+	}
+
+	if _, ok := client.RetailAddons.(*v1Client); !ok {
+		t.Errorf("DetectAPIVersion() did not switch RetailAddons to the v1 API")
+	}
+
+	mux.HandleFunc("/api.php", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	client.SetV1BaseURL(client.url + "/missing")
+
+	version, err = client.DetectAPIVersion(context.Background())
+	if err != nil || version != APILegacy || client.APIVersion() != APILegacy {
+		t.Errorf("DetectAPIVersion() without v1 API returned %v, %v, want %v", version, err, APILegacy)
+	}
+
+	client.SetBaseURL(client.v1URL)
+
+	if _, err := client.DetectAPIVersion(context.Background()); err == nil {
+		t.Errorf("DetectAPIVersion() without any API returned no error")
+	}
+}
+
+func TestParseAPIVersion(t *testing.T) {
+	for _, version := range []APIVersion{APILegacy, APIV1} {
+		got, err := ParseAPIVersion(version.String())
+		if err != nil || got != version {
+			t.Errorf("ParseAPIVersion(%q) returned %v, %v", version, got, err)
+		}
+	}
+
+	if _, err := ParseAPIVersion("v2"); err == nil {
+		t.Errorf("ParseAPIVersion(%q) returned no error", "v2")
+	}
+}
+
+func TestClient_SetAPIVersion_Unknown(t *testing.T) {
+	client := NewClient(nil)
+
+	if err := client.SetAPIVersion(APIVersion(7)); err == nil {
+		t.Errorf("SetAPIVersion(7) returned no error")
+	}
+
+	if client.APIVersion() != APILegacy {
+		t.Errorf("APIVersion() returned %v, want %v", client.APIVersion(), APILegacy)
+	}
+}
+
+func TestAddon_PatchFor(t *testing.T) {
+	tests := []struct {
+		addon  Addon
+		flavor Flavor
+		want   string
+		ok     bool
+	}{
+		{Addon{Patches: []string{"10.1.5", "1.14.4"}}, FlavorClassic, "1.14.4", true},
+		{Addon{Patches: []string{"10.1.5", "1.14.4"}}, FlavorCataclysm, "", false},
+		{Addon{Patch: String("3.4.2")}, FlavorWrath, "3.4.2", true},
+		{Addon{Patch: String("invalid")}, FlavorRetail, "", false},
+		{Addon{}, FlavorRetail, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := tt.addon.PatchFor(tt.flavor)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("PatchFor(%s) returned %q, %v, want %q, %v", tt.flavor, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,493 @@
+package tukui
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoBackup is returned if there is no snapshot to roll back to.
+var ErrNoBackup = errors.New("no backup of the addon")
+
+const snapshotMeta = "tukui-backup.json"
+
+// BackupOptions enables snapshots of installed addons before they are replaced by an update.
+type BackupOptions struct {
+	// Dir holds the snapshots, one directory per addon ID
+	Dir string
+	// WTFDir is the WTF directory of the game client. If set, the SavedVariables
+	// of the addon folders are part of the snapshots.
+	WTFDir string
+	// Keep is the number of snapshots kept per addon, 0 keeps all
+	Keep int
+}
+
+// A Snapshot is a .zip archive of the folders, and optionally the SavedVariables,
+// of an installed addon taken before it was replaced.
+type Snapshot struct {
+	// Entry is the manifest entry of the replaced installation
+	Entry ManifestEntry `json:"entry"`
+	// CreatedAt is the time the snapshot was taken
+	CreatedAt time.Time `json:"created_at"`
+	// SavedVariables are the slash separated paths of the files below the WTF directory
+	SavedVariables []string `json:"saved_variables,omitempty"`
+	// Path of the archive
+	Path string `json:"-"`
+}
+
+// Snapshots returns the snapshots of the addon with the given ID, newest first.
+func (i *Installer) Snapshots(id string) ([]Snapshot, error) {
+	if i.Backup == nil {
+		return nil, nil
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.snapshots(id)
+}
+
+// Rollback restores the newest snapshot of the addon with the given version, or the
+// newest snapshot at all if toVersion is empty. The folders of the current version
+// are replaced, including SavedVariables if the snapshot has any, and the manifest
+// records the restored version.
+//
+// The current version is archived first, so a rollback can be undone by rolling
+// back to it. The restored snapshot is removed, as its version is installed again,
+// and the snapshots beyond BackupOptions.Keep are pruned.
+func (i *Installer) Rollback(id, toVersion string) (ManifestEntry, error) {
+	if i.Backup == nil {
+		return ManifestEntry{}, errors.New("backups are not enabled")
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	snapshots, err := i.snapshots(id)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+
+	var snapshot *Snapshot
+	for n := range snapshots {
+		if toVersion == "" || snapshots[n].Entry.Version == toVersion {
+			snapshot = &snapshots[n]
+			break
+		}
+	}
+
+	if snapshot == nil {
+		return ManifestEntry{}, fmt.Errorf("%w: %s %s", ErrNoBackup, id, toVersion)
+	}
+
+	manifest, err := i.Manifest()
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+
+	if current, ok := manifest.Entry(id); ok {
+		if err := i.snapshot(current); err != nil {
+			return ManifestEntry{}, fmt.Errorf("backup of %s %s: %w", id, current.Version, err)
+		}
+	}
+
+	if err := i.restore(*snapshot, manifest); err != nil {
+		return ManifestEntry{}, err
+	}
+
+	entry := snapshot.Entry
+	entry.InstalledAt = time.Now().UTC()
+	manifest.set(entry)
+
+	if err := manifest.Write(i.ManifestPath()); err != nil {
+		return entry, err
+	}
+
+	if err := os.Remove(snapshot.Path); err != nil {
+		return entry, err
+	}
+
+	return entry, i.pruneSnapshots(id)
+}
+
+// snapshotDir returns the directory holding the snapshots of the addon.
+func (i *Installer) snapshotDir(id string) (string, error) {
+	if !isFolderName(id) {
+		return "", fmt.Errorf("%w: addon id %s", ErrUnsafePath, id)
+	}
+
+	return filepath.Join(i.Backup.Dir, id), nil
+}
+
+func (i *Installer) snapshots(id string) ([]Snapshot, error) {
+	dir, err := i.snapshotDir(id)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") || filepath.Ext(file.Name()) != ".zip" {
+			continue
+		}
+
+		s, err := readSnapshot(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	sort.SliceStable(snapshots, func(a, b int) bool {
+		return snapshots[a].CreatedAt.After(snapshots[b].CreatedAt)
+	})
+
+	return snapshots, nil
+}
+
+func readSnapshot(path string) (Snapshot, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.Name != snapshotMeta {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return Snapshot{}, err
+		}
+		defer rc.Close()
+
+		var s Snapshot
+		if err := json.NewDecoder(rc).Decode(&s); err != nil {
+			return Snapshot{}, fmt.Errorf("reading snapshot %s: %w", path, err)
+		}
+		s.Path = path
+
+		return s, nil
+	}
+
+	return Snapshot{}, fmt.Errorf("snapshot %s has no %s", path, snapshotMeta)
+}
+
+// snapshot archives the folders of the installed entry. Snapshots beyond the
+// retention limit are removed by pruneSnapshots.
+func (i *Installer) snapshot(entry ManifestEntry) error {
+	dir, err := i.snapshotDir(entry.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	s := Snapshot{Entry: entry, CreatedAt: time.Now().UTC()}
+
+	files := make(map[string]string)
+	for _, folder := range entry.Folders {
+		if !isFolderName(folder) {
+			return fmt.Errorf("%w: %s", ErrUnsafePath, folder)
+		}
+
+		if err := collectFiles(filepath.Join(i.addonsDir, folder), "AddOns/"+folder, files); err != nil {
+			return err
+		}
+	}
+
+	if i.Backup.WTFDir != "" {
+		s.SavedVariables, err = findSavedVariables(i.Backup.WTFDir, entry.Folders)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range s.SavedVariables {
+			files["WTF/"+name] = filepath.Join(i.Backup.WTFDir, filepath.FromSlash(name))
+		}
+	}
+
+	f, err := ioutil.TempFile(dir, ".snapshot-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := writeSnapshot(f, s, files); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	name := s.CreatedAt.Format("20060102T150405.000000000Z") + ".zip"
+
+	return os.Rename(f.Name(), filepath.Join(dir, name))
+}
+
+func (i *Installer) pruneSnapshots(id string) error {
+	if i.Backup.Keep <= 0 {
+		return nil
+	}
+
+	snapshots, err := i.snapshots(id)
+	if err != nil {
+		return err
+	}
+
+	for n := i.Backup.Keep; n < len(snapshots); n++ {
+		if err := os.Remove(snapshots[n].Path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// collectFiles adds all regular files below dir to files, keyed by their name in
+// the archive below prefix. A missing dir is skipped.
+func collectFiles(dir, prefix string, files map[string]string) error {
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		files[path.Join(prefix, filepath.ToSlash(rel))] = p
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// findSavedVariables returns the account and character wide SavedVariables files
+// of the given addon folders below wtfDir.
+func findSavedVariables(wtfDir string, folders []string) ([]string, error) {
+	names := make(map[string]bool)
+	for _, folder := range folders {
+		names[strings.ToLower(folder)+".lua"] = true
+		names[strings.ToLower(folder)+".lua.bak"] = true
+	}
+
+	var found []string
+	err := filepath.Walk(wtfDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() || !names[strings.ToLower(info.Name())] ||
+			!strings.EqualFold(filepath.Base(filepath.Dir(p)), "SavedVariables") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(wtfDir, p)
+		if err != nil {
+			return err
+		}
+
+		found = append(found, filepath.ToSlash(rel))
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return found, err
+}
+
+func writeSnapshot(w io.Writer, s Snapshot, files map[string]string) error {
+	zw := zip.NewWriter(w)
+
+	meta, err := zw.Create(snapshotMeta)
+	if err != nil {
+		return err
+	}
+
+	if err := json.NewEncoder(meta).Encode(s); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := addFileToZip(zw, name, files[name]); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func addFileToZip(zw *zip.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// restore replaces the installed folders of the addon with the folders of the snapshot.
+// Folders of the current installation missing in the snapshot are removed, unless
+// another addon of the manifest owns them.
+func (i *Installer) restore(s Snapshot, manifest *Manifest) error {
+	r, err := zip.OpenReader(s.Path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err := os.MkdirAll(i.addonsDir, 0755); err != nil {
+		return err
+	}
+
+	unlock := lockAddonsDir(i.addonsDir)
+	defer unlock()
+
+	removeStaleStaging(i.addonsDir)
+
+	staging, err := ioutil.TempDir(i.addonsDir, stagingPrefix)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	maxSize, _ := i.Extract.limits()
+	remaining := maxSize
+	savedVariables := make(map[string]*zip.File)
+
+	for _, f := range r.File {
+		name, err := zipEntryName(f)
+		if err != nil {
+			return err
+		}
+
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(name, "AddOns/"):
+			n, err := extractFile(f, filepath.Join(staging, filepath.FromSlash(strings.TrimPrefix(name, "AddOns/"))), remaining)
+			if err != nil {
+				return err
+			}
+			remaining -= n
+		case strings.HasPrefix(name, "WTF/"):
+			savedVariables[strings.TrimPrefix(name, "WTF/")] = f
+		}
+	}
+
+	folders, err := ioutil.ReadDir(staging)
+	if err != nil {
+		return err
+	}
+
+	if len(folders) == 0 {
+		return fmt.Errorf("%w: snapshot %s", ErrNoAddonFolder, s.Path)
+	}
+
+	names := make([]string, 0, len(folders))
+	for _, folder := range folders {
+		names = append(names, folder.Name())
+	}
+
+	if err := replaceFolders(staging, i.addonsDir, names); err != nil {
+		return err
+	}
+
+	if current, ok := manifest.Entry(s.Entry.ID); ok {
+		if err := i.removeStaleFolders(manifest, current, s.Entry.Folders); err != nil {
+			return err
+		}
+	}
+
+	if i.Backup.WTFDir == "" {
+		return nil
+	}
+
+	for name, f := range savedVariables {
+		if err := restoreFile(f, filepath.Join(i.Backup.WTFDir, filepath.FromSlash(name))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func restoreFile(f *zip.File, target string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(target, data)
+}
@@ -0,0 +1,222 @@
+package tukui
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func TestInstaller_Rollback(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	payload := addonZip(t, "1.0")
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		serveZip(`"v"`, payload)(w, r)
+	})
+
+	installer, addon := newTestInstaller(t, client, "1.0")
+	gameDir := filepath.Dir(filepath.Dir(installer.addonsDir))
+	wtfDir := filepath.Join(gameDir, "WTF")
+	savedVariables := filepath.Join(wtfDir, "Account", "ME", "SavedVariables", "AddOnSkins.lua")
+
+	installer.Backup = &BackupOptions{Dir: filepath.Join(gameDir, "Backups"), WTFDir: wtfDir, Keep: 2}
+
+	install := func(version string, settings string) {
+		t.Helper()
+		if version == "3.0" {
+			payload = buildZip(t, zipEntry{name: "AddOnSkins/AddOnSkins.toc", content: "## Version: 3.0"})
+		} else {
+			payload = addonZip(t, version)
+		}
+		addon.Version = String(version)
+
+		if _, err := installer.Install(context.Background(), addon); err != nil {
+			t.Fatalf("Installer.Install(%s) returned error: %v", version, err)
+		}
+
+		// the game writes the settings while the version is in use
+		writeAddOns(t, wtfDir, map[string]string{"Account/ME/SavedVariables/AddOnSkins.lua": settings})
+	}
+
+	install("1.0", "settings 1")
+	install("2.0", "settings 2")
+	install("3.0", "settings 3")
+	install("4.0", "settings 4")
+
+	snapshots, err := installer.Snapshots("3")
+	if err != nil {
+		t.Fatalf("Installer.Snapshots() returned error: %v", err)
+	}
+
+	var versions []string
+	for _, s := range snapshots {
+		versions = append(versions, s.Entry.Version)
+	}
+	if want := []string{"3.0", "2.0"}; !cmp.Equal(versions, want) {
+		t.Errorf("Installer.Snapshots() returned versions %v, want %v", versions, want)
+	}
+
+	wantSaved := []string{"Account/ME/SavedVariables/AddOnSkins.lua"}
+	if !cmp.Equal(snapshots[0].SavedVariables, wantSaved) {
+		t.Errorf("Installer.Snapshots() returned SavedVariables %v, want %v", snapshots[0].SavedVariables, wantSaved)
+	}
+
+	// 3.0 has a single folder, rolling back removes AddOnSkins_Plugins of 4.0
+	entry, err := installer.Rollback("3", "3.0")
+	if err != nil {
+		t.Fatalf("Installer.Rollback() returned error: %v", err)
+	}
+
+	if entry.Version != "3.0" || !cmp.Equal(entry.Folders, []string{"AddOnSkins"}) {
+		t.Errorf("Installer.Rollback() returned %+v", entry)
+	}
+
+	if got := listFiles(t, installer.addonsDir); !cmp.Equal(got, []string{"AddOnSkins/AddOnSkins.toc"}) {
+		t.Errorf("Installer.Rollback() left %v", got)
+	}
+
+	if got := readFile(t, savedVariables); got != "settings 3" {
+		t.Errorf("Installer.Rollback() restored SavedVariables %q, want %q", got, "settings 3")
+	}
+
+	entry, err = installer.Rollback("3", "2.0")
+	if err != nil {
+		t.Fatalf("Installer.Rollback() returned error: %v", err)
+	}
+
+	if got := readFile(t, filepath.Join(installer.addonsDir, "AddOnSkins_Plugins", "AddOnSkins_Plugins.toc")); got != "## Version: 2.0" {
+		t.Errorf("Installer.Rollback() restored %q, want version 2.0", got)
+	}
+
+	manifest, err := installer.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := manifest.Entry("3"); got.Version != "2.0" || got.SHA256 != entry.SHA256 {
+		t.Errorf("Installer.Rollback() recorded %+v, want %+v", got, entry)
+	}
+
+	// the restored snapshots are installed again, the replaced versions are archived
+	if got, want := snapshotVersions(t, installer, "3"), []string{"3.0", "4.0"}; !cmp.Equal(got, want) {
+		t.Errorf("Installer.Rollback() left snapshots of %v, want %v", got, want)
+	}
+}
+
+func snapshotVersions(t *testing.T, installer *Installer, id string) []string {
+	t.Helper()
+	snapshots, err := installer.Snapshots(id)
+	if err != nil {
+		t.Fatalf("Installer.Snapshots() returned error: %v", err)
+	}
+
+	var versions []string
+	for _, s := range snapshots {
+		versions = append(versions, s.Entry.Version)
+	}
+
+	return versions
+}
+
+func TestInstaller_Install_FailedUpdateKeepsSnapshots(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	payload := addonZip(t, "1.0")
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		serveZip(`"v"`, payload)(w, r)
+	})
+
+	installer, addon := newTestInstaller(t, client, "1.0")
+	installer.Backup = &BackupOptions{Dir: filepath.Join(tempDir(t), "Backups"), Keep: 1}
+
+	for _, version := range []string{"1.0", "2.0"} {
+		payload = addonZip(t, version)
+		addon.Version = String(version)
+		if _, err := installer.Install(context.Background(), addon); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	payload = buildZip(t, zipEntry{name: "AddOnSkins.toc", content: "## Version: 3.0"})
+	addon.Version = String("3.0")
+	if _, err := installer.Install(context.Background(), addon); !errors.Is(err, ErrNoAddonFolder) {
+		t.Fatalf("Installer.Install() returned %v, want %v", err, ErrNoAddonFolder)
+	}
+
+	if got, want := snapshotVersions(t, installer, "3"), []string{"2.0", "1.0"}; !cmp.Equal(got, want) {
+		t.Errorf("Installer.Install() left snapshots of %v, want %v", got, want)
+	}
+}
+
+func TestInstaller_Rollback_Forward(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	payload := addonZip(t, "1.0")
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		serveZip(`"v"`, payload)(w, r)
+	})
+
+	installer, addon := newTestInstaller(t, client, "1.0")
+	installer.Backup = &BackupOptions{Dir: filepath.Join(tempDir(t), "Backups"), Keep: 1}
+
+	if _, err := installer.Install(context.Background(), addon); err != nil {
+		t.Fatal(err)
+	}
+
+	payload = addonZip(t, "2.0")
+	addon.Version = String("2.0")
+	if _, err := installer.Install(context.Background(), addon); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := installer.Rollback("3", "1.0"); err != nil {
+		t.Fatalf("Installer.Rollback() returned error: %v", err)
+	}
+
+	// the version rolled back from was archived and can be restored again
+	entry, err := installer.Rollback("3", "2.0")
+	if err != nil {
+		t.Fatalf("Installer.Rollback() to the newer version returned error: %v", err)
+	}
+
+	if entry.Version != "2.0" {
+		t.Errorf("Installer.Rollback() returned version %s, want %s", entry.Version, "2.0")
+	}
+
+	for _, folder := range []string{"AddOnSkins", "AddOnSkins_Plugins"} {
+		if got := readFile(t, filepath.Join(installer.addonsDir, folder, folder+".toc")); got != "## Version: 2.0" {
+			t.Errorf("Installer.Rollback() restored %s %q, want version 2.0", folder, got)
+		}
+	}
+
+	if _, err := installer.Rollback("3", "1.0"); err != nil {
+		t.Errorf("Installer.Rollback() back to the older version returned error: %v", err)
+	}
+}
+
+func TestInstaller_Rollback_NoBackup(t *testing.T) {
+	installer := setupInstalled(t, []ManifestEntry{{ID: "3", Version: "1.0", Folders: []string{"AddOnSkins"}}}, nil)
+	installer.Backup = &BackupOptions{Dir: tempDir(t)}
+
+	if _, err := installer.Rollback("3", "0.9"); !errors.Is(err, ErrNoBackup) {
+		t.Errorf("Installer.Rollback() returned %v, want %v", err, ErrNoBackup)
+	}
+}
@@ -0,0 +1,212 @@
+package tukui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Products of the game clients as named in the .build.info file.
+const (
+	ProductRetail        = "wow"
+	ProductClassic       = "wow_classic"
+	ProductClassicEra    = "wow_classic_era"
+	ProductRetailPTR     = "wowt"
+	ProductClassicPTR    = "wow_classic_ptr"
+	ProductClassicEraPTR = "wow_classic_era_ptr"
+	ProductBeta          = "wow_beta"
+)
+
+// productDirs maps the products to the folder of the game client in the installation
+var productDirs = map[string]string{
+	ProductRetail:        "_retail_",
+	ProductClassic:       "_classic_",
+	ProductClassicEra:    "_classic_era_",
+	ProductRetailPTR:     "_ptr_",
+	ProductClassicPTR:    "_classic_ptr_",
+	ProductClassicEraPTR: "_classic_era_ptr_",
+	ProductBeta:          "_beta_",
+}
+
+// ErrNoBuildInfo is returned if a directory has no .build.info file.
+var ErrNoBuildInfo = errors.New("no .build.info file found")
+
+// An Installation is a game client installed in a World of Warcraft directory.
+type Installation struct {
+	// Product of the game client, e.g. wow or wow_classic_era
+	Product string
+	// Version of the game client, e.g. 1.15.2.55140
+	Version string
+	// Build number of the game client, e.g. 55140
+	Build int
+	// Interface number of the game client, e.g. 11502
+	Interface int
+	// Flavor of the game client derived from the interface number
+	Flavor Flavor
+	// Dir is the directory of the game client, e.g. World of Warcraft/_classic_era_
+	Dir string
+}
+
+// AddOnsDir returns the Interface/AddOns directory of the game client. Existing
+// directories are matched case-insensitively, which matters for installations
+// running under Wine on case-sensitive file systems.
+func (i Installation) AddOnsDir() string {
+	return findDirFold(findDirFold(i.Dir, "Interface"), "AddOns")
+}
+
+// WTFDir returns the WTF directory of the game client holding the SavedVariables.
+func (i Installation) WTFDir() string {
+	return findDirFold(i.Dir, "WTF")
+}
+
+// findDirFold returns the existing entry of dir matching name case-insensitively,
+// or dir joined with name if there is none.
+func findDirFold(dir, name string) string {
+	entries, err := ioutil.ReadDir(dir)
+	if err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() && entry.Name() != name && strings.EqualFold(entry.Name(), name) {
+				return filepath.Join(dir, entry.Name())
+			}
+		}
+	}
+
+	return filepath.Join(dir, name)
+}
+
+// DetectInstallations reads the .build.info file in the World of Warcraft root directory
+// and returns the active game clients installed there. Clients whose directory does not
+// exist are skipped.
+func DetectInstallations(root string) ([]Installation, error) {
+	f, err := os.Open(filepath.Join(root, ".build.info"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: %w", root, ErrNoBuildInfo)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	installations, err := ReadBuildInfo(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []Installation
+	for _, inst := range installations {
+		dir, ok := productDirs[inst.Product]
+		if !ok {
+			continue
+		}
+
+		inst.Dir = filepath.Join(root, dir)
+		if info, err := os.Stat(inst.Dir); err != nil || !info.IsDir() {
+			continue
+		}
+
+		found = append(found, inst)
+	}
+
+	return found, nil
+}
+
+// ReadBuildInfo parses the content of a .build.info file. It returns one Installation
+// per active product, Dir is left empty.
+func ReadBuildInfo(r io.Reader) ([]Installation, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("empty .build.info")
+	}
+
+	columns := make(map[string]int)
+	for i, column := range strings.Split(strings.TrimPrefix(scanner.Text(), "\ufeff"), "|") {
+		// columns have the form Name!TYPE:size
+		name := strings.SplitN(column, "!", 2)[0]
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	for _, required := range []string{"Version", "Product"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("invalid .build.info: no %s column", required)
+		}
+	}
+
+	field := func(values []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(values) {
+			return ""
+		}
+		return strings.TrimSpace(values[i])
+	}
+
+	var installations []Installation
+	seen := make(map[string]bool)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		values := strings.Split(line, "|")
+		if active := field(values, "Active"); active == "0" {
+			continue
+		}
+
+		product := field(values, "Product")
+		if product == "" || seen[product] {
+			continue
+		}
+
+		inst, err := parseClientVersion(field(values, "Version"))
+		if err != nil {
+			return nil, err
+		}
+
+		inst.Product = product
+		seen[product] = true
+		installations = append(installations, inst)
+	}
+
+	return installations, scanner.Err()
+}
+
+// parseClientVersion parses a client version like 10.2.7.55664.
+func parseClientVersion(v string) (Installation, error) {
+	i := strings.LastIndexByte(v, '.')
+	if i < 0 {
+		return Installation{}, fmt.Errorf("invalid client version %q", v)
+	}
+
+	build, err := strconv.Atoi(v[i+1:])
+	if err != nil {
+		return Installation{}, fmt.Errorf("invalid client version %q", v)
+	}
+
+	iface, err := PatchToInterface(v[:i])
+	if err != nil {
+		return Installation{}, fmt.Errorf("invalid client version %q", v)
+	}
+
+	return Installation{
+		Version:   v,
+		Build:     build,
+		Interface: iface,
+		Flavor:    InterfaceFlavor(iface),
+	}, nil
+}
+
+// AddonsForInstallation returns the AddonClient for the flavor of the installed game client.
+func (c *Client) AddonsForInstallation(inst Installation) (AddonClient, error) {
+	return c.AddonsFor(inst.Flavor)
+}
@@ -0,0 +1,97 @@
+package tukui
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testBuildInfo = "Branch!STRING:0|Active!DEC:1|Build Key!HEX:16|CDN Key!HEX:16|Install Key!HEX:16|IM Size!DEC:4|CDN Path!STRING:0|CDN Hosts!STRING:0|CDN Servers!STRING:0|Tags!STRING:0|Armadillo!STRING:0|Last Activated!STRING:0|Version!STRING:0|KeyRing!HEX:16|Product!STRING:0\r\n" +
+	"eu|1|1f2e|3d4c|5b6a||tpr/wow|eu.cdn.blizzard.com|http://eu.cdn.blizzard.com/|Windows x86_64 EU? enUS speech?:Windows x86_64 EU? enUS text?|||10.2.7.55664|3ca5|wow\r\n" +
+	"us|1|1f2e|3d4c|5b6a||tpr/wow|us.cdn.blizzard.com|http://us.cdn.blizzard.com/|Windows x86_64 US? enUS speech?:Windows x86_64 US? enUS text?|||10.2.7.55664|3ca5|wow\r\n" +
+	"eu|1|7a8b|9c0d|1e2f||tpr/wow|eu.cdn.blizzard.com|http://eu.cdn.blizzard.com/|Windows x86_64 EU? enUS speech?:Windows x86_64 EU? enUS text?|||1.15.2.55140|3ca5|wow_classic_era\r\n" +
+	"eu|1|3a4b|5c6d|7e8f||tpr/wow|eu.cdn.blizzard.com|http://eu.cdn.blizzard.com/|Windows x86_64 EU? enUS speech?:Windows x86_64 EU? enUS text?|||4.4.0.54737|3ca5|wow_classic\r\n" +
+	"eu|0|3a4b|5c6d|7e8f||tpr/wow|eu.cdn.blizzard.com|http://eu.cdn.blizzard.com/|Windows x86_64 EU? enUS speech?:Windows x86_64 EU? enUS text?|||11.0.0.55000|3ca5|wow_beta\r\n"
+
+func TestReadBuildInfo(t *testing.T) {
+	installations, err := ReadBuildInfo(strings.NewReader(testBuildInfo))
+	if err != nil {
+		t.Fatalf("ReadBuildInfo() returned error: %v", err)
+	}
+
+	want := []Installation{
+		{Product: ProductRetail, Version: "10.2.7.55664", Build: 55664, Interface: 100207, Flavor: FlavorRetail},
+		{Product: ProductClassicEra, Version: "1.15.2.55140", Build: 55140, Interface: 11502, Flavor: FlavorClassic},
+		{Product: ProductClassic, Version: "4.4.0.54737", Build: 54737, Interface: 40400, Flavor: FlavorCataclysm},
+	}
+	if !cmp.Equal(installations, want) {
+		t.Errorf("ReadBuildInfo() returned %+v, want %+v", installations, want)
+	}
+}
+
+func TestReadBuildInfo_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"Branch!STRING:0|Active!DEC:1\neu|1\n",
+		"Version!STRING:0|Product!STRING:0\nlatest|wow\n",
+	}
+
+	for _, content := range tests {
+		if _, err := ReadBuildInfo(strings.NewReader(content)); err == nil {
+			t.Errorf("ReadBuildInfo(%q) returned no error", content)
+		}
+	}
+}
+
+func TestDetectInstallations(t *testing.T) {
+	root := tempDir(t)
+	if err := ioutil.WriteFile(filepath.Join(root, ".build.info"), []byte(testBuildInfo), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// classic era was installed under Wine, which created a lower case interface folder
+	for _, dir := range []string{"_retail_", "_classic_era_/interface/AddOns"} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	installations, err := DetectInstallations(root)
+	if err != nil {
+		t.Fatalf("DetectInstallations() returned error: %v", err)
+	}
+
+	if len(installations) != 2 {
+		t.Fatalf("DetectInstallations() returned %+v, want retail and classic era", installations)
+	}
+
+	wantDirs := []string{
+		filepath.Join(root, "_retail_", "Interface", "AddOns"),
+		filepath.Join(root, "_classic_era_", "interface", "AddOns"),
+	}
+	for i, inst := range installations {
+		if got := inst.AddOnsDir(); got != wantDirs[i] {
+			t.Errorf("Installation.AddOnsDir() of %s returned %q, want %q", inst.Product, got, wantDirs[i])
+		}
+	}
+
+	client := NewClient(nil)
+	for i, want := range []AddonClient{client.RetailAddons, client.ClassicAddons} {
+		got, err := client.AddonsForInstallation(installations[i])
+		if err != nil || got != want {
+			t.Errorf("Client.AddonsForInstallation(%s) returned %v, %v", installations[i].Product, got, err)
+		}
+	}
+}
+
+func TestDetectInstallations_NoBuildInfo(t *testing.T) {
+	_, err := DetectInstallations(tempDir(t))
+	if !errors.Is(err, ErrNoBuildInfo) {
+		t.Errorf("DetectInstallations() returned %v, want %v", err, ErrNoBuildInfo)
+	}
+}
@@ -1,16 +1,47 @@
 package tukui
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+)
 
 const baseURL = "https://www.tukui.org/api.php"
 
 // The Client is a simple http client to access the TukUI.org API.
-// Addons can be accessed either by the RetailAddons or ClassicAddons field.
+// Addons can be accessed either by the RetailAddons or ClassicAddons field. The
+// Client uses the api.php unless another API version is set with SetAPIVersion.
 type Client struct {
 	url           string
 	httpClient    *http.Client
 	RetailAddons  AddonClient
 	ClassicAddons AddonClient
+	warn          func(Warning)
+	strict        bool
+	v1URL         string
+	version       APIVersion
+}
+
+// A Warning reports a problem with a response that did not fail the query, like a
+// record of the catalog that could not be decoded and was skipped.
+type Warning struct {
+	// Query of the response, e.g. addons=all, or the path below the v1 API, e.g. addons
+	Query string
+	// Index of the record in the list, -1 for responses with a single record
+	Index int
+	// Err describes the problem
+	Err error
+}
+
+func (w Warning) Error() string {
+	if w.Index < 0 {
+		return fmt.Sprintf("%s: %v", w.Query, w.Err)
+	}
+
+	return fmt.Sprintf("%s: record %d: %v", w.Query, w.Index, w.Err)
+}
+
+func (w Warning) Unwrap() error {
+	return w.Err
 }
 
 // NewClient creates a new Client struct and returns a pointer to it.
@@ -23,6 +54,7 @@ func NewClient(client *http.Client) *Client {
 
 	c := Client{
 		url:        baseURL,
+		v1URL:      v1BaseURL,
 		httpClient: client,
 	}
 	c.RetailAddons = newRetailClient(&c)
@@ -30,3 +62,28 @@ func NewClient(client *http.Client) *Client {
 
 	return &c
 }
+
+// SetBaseURL changes the URL of the API, e.g. to use a mirror or a local test server.
+func (c *Client) SetBaseURL(url string) {
+	c.url = url
+}
+
+// SetWarningHandler sets the function called for every Warning. Warnings are dropped
+// if no handler is set. The handler must be safe for concurrent use if the Client is.
+func (c *Client) SetWarningHandler(handler func(Warning)) {
+	c.warn = handler
+}
+
+// SetStrict enables the strict decoding mode. A strict Client reports every field
+// of a response that the Addon type does not know as a Warning wrapping
+// ErrUnknownField, which detects changes of the schema of the API. The fields are
+// kept in Addon.Extra in either mode.
+func (c *Client) SetStrict(strict bool) {
+	c.strict = strict
+}
+
+func (c *Client) warning(w Warning) {
+	if c.warn != nil {
+		c.warn(w)
+	}
+}
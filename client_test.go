@@ -1,6 +1,7 @@
 package tukui
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -40,3 +41,127 @@ func testHTTPQuery(t *testing.T, r *http.Request, want url.Values) {
 func String(s string) *string {
 	return &s
 }
+
+func TestClient_SetBaseURL(t *testing.T) {
+	var requested bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mirror/api.php", func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+		w.Write([]byte(`[]`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(nil)
+	client.SetBaseURL(server.URL + "/mirror/api.php")
+
+	if _, _, err := client.RetailAddons.GetAddons(); err != nil {
+		t.Fatalf("GetAddons() returned error: %v", err)
+	}
+
+	if !requested {
+		t.Errorf("Client.SetBaseURL() did not change the URL of the API")
+	}
+}
+
+func TestWarning_Error(t *testing.T) {
+	err := errors.New("cannot decode object into a scalar")
+
+	tests := []struct {
+		warning Warning
+		want    string
+	}{
+		{Warning{Query: "addons=all", Index: 4, Err: err}, "addons=all: record 4: cannot decode object into a scalar"},
+		{Warning{Query: "ui=elvui", Index: -1, Err: err}, "ui=elvui: cannot decode object into a scalar"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.warning.Error(); got != tt.want {
+			t.Errorf("Warning.Error() returned %q, want %q", got, tt.want)
+		}
+
+		if !errors.Is(tt.warning, err) {
+			t.Errorf("Warning does not unwrap to %v", err)
+		}
+	}
+}
+
+func TestClient_SetStrict(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("addons") {
+		case "all":
+			w.Write([]byte(`[
+				{"id": "3", "gallery_url": "https://www.tukui.org/addons/3/gallery"},
+				{"id": "6", "gallery_url": "https://www.tukui.org/addons/6/gallery", "logo_square_url": "https://www.tukui.org/addons/6/logo.png"}
+			]`))
+		default:
+			w.Write([]byte(`{"id": -2, "name": "ElvUI", "tags": ["ui"]}`))
+		}
+	})
+
+	var warnings []string
+	client.SetWarningHandler(func(w Warning) {
+		if !errors.Is(w, ErrUnknownField) {
+			t.Errorf("warning %v does not wrap %v", w, ErrUnknownField)
+		}
+		warnings = append(warnings, w.Error())
+	})
+
+	if _, _, err := client.RetailAddons.GetAddons(); err != nil {
+		t.Fatalf("GetAddons() returned error: %v", err)
+	}
+
+	if len(warnings) != 0 {
+		t.Errorf("GetAddons() without strict mode warned %v", warnings)
+	}
+
+	client.SetStrict(true)
+
+	if _, _, err := client.RetailAddons.GetAddons(); err != nil {
+		t.Fatalf("GetAddons() returned error: %v", err)
+	}
+
+	if _, _, err := client.RetailAddons.GetElvUI(); err != nil {
+		t.Fatalf("GetElvUI() returned error: %v", err)
+	}
+
+	want := []string{
+		`addons=all: record 0: unknown field "gallery_url"`,
+		`addons=all: record 1: unknown field "logo_square_url"`,
+		`ui=elvui: unknown field "tags"`,
+	}
+
+	if !cmp.Equal(warnings, want) {
+		t.Errorf("strict Client warned %q, want %q", warnings, want)
+	}
+}
+
+func TestClient_SetStrict_SkippedRecord(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": {"x": 1}}, {"id": "3", "gallery_url": "x"}]`))
+	})
+
+	var warnings []string
+	client.SetWarningHandler(func(w Warning) {
+		if errors.Is(w, ErrUnknownField) {
+			warnings = append(warnings, w.Error())
+		}
+	})
+	client.SetStrict(true)
+
+	if _, _, err := client.RetailAddons.GetAddons(); err != nil {
+		t.Fatalf("GetAddons() returned error: %v", err)
+	}
+
+	want := []string{`addons=all: record 1: unknown field "gallery_url"`}
+	if !cmp.Equal(warnings, want) {
+		t.Errorf("strict Client warned %q, want %q", warnings, want)
+	}
+}
@@ -0,0 +1,394 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/unly/go-tukui"
+	"github.com/unly/go-tukui/formatter"
+	"github.com/unly/go-tukui/version"
+)
+
+// app holds the state shared by the commands.
+type app struct {
+	stdout    io.Writer
+	stderr    io.Writer
+	client    *tukui.Client
+	flavor    tukui.Flavor
+	addons    tukui.AddonClient
+	addonsDir string
+	output    *formatter.Options
+	dryRun    bool
+
+	catalog []tukui.Addon
+}
+
+func newApp(opts options, stdout, stderr io.Writer) (*app, error) {
+	flavor, err := tukui.ParseFlavor(opts.flavor)
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := tukui.ParseAPIVersion(opts.api)
+	if err != nil {
+		return nil, err
+	}
+
+	client := tukui.NewClient(nil)
+	if err := client.SetAPIVersion(api); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case opts.apiURL == "":
+	case api == tukui.APIV1:
+		client.SetV1BaseURL(opts.apiURL)
+	default:
+		client.SetBaseURL(opts.apiURL)
+	}
+	client.SetWarningHandler(func(w tukui.Warning) {
+		fmt.Fprintf(stderr, "tukui: warning: %v\n", w)
+	})
+
+	addons, err := client.AddonsFor(flavor)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := formatter.ParseFormat(opts.output)
+	if err != nil {
+		return nil, err
+	}
+
+	return &app{
+		stdout:    stdout,
+		stderr:    stderr,
+		client:    client,
+		flavor:    flavor,
+		addons:    addons,
+		addonsDir: opts.addonsDir,
+		output:    &formatter.Options{Format: format, Fields: formatter.ParseFields(opts.fields)},
+		dryRun:    opts.dryRun,
+	}, nil
+}
+
+// fetchCatalog returns all addons of the flavor including the UI suites. The
+// catalog is fetched once.
+func (a *app) fetchCatalog() ([]tukui.Addon, error) {
+	if a.catalog != nil {
+		return a.catalog, nil
+	}
+
+	addons, err := tukui.FetchCatalog(context.Background(), a.addons)
+	if err != nil {
+		return nil, err
+	}
+
+	a.catalog = addons
+
+	return addons, nil
+}
+
+// lookup returns the addon for an ID or a slug, which includes the names of the
+// UI suites.
+func (a *app) lookup(arg string) (tukui.Addon, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		addon, _, err := a.addons.GetAddonBySlug(context.Background(), arg)
+		return addon, err
+	}
+
+	addon, _, err := a.addons.GetAddon(id)
+	if err != nil {
+		return tukui.Addon{}, err
+	}
+
+	if addon.Id == nil {
+		return tukui.Addon{}, fmt.Errorf("%w: addon %s", errNotFound, arg)
+	}
+
+	return addon, nil
+}
+
+func (a *app) installer() (*tukui.Installer, error) {
+	if a.addonsDir == "" {
+		return nil, fmt.Errorf("%w: -addons-dir is required", errUsage)
+	}
+
+	return tukui.NewInstaller(a.client, a.flavor, a.addonsDir), nil
+}
+
+func runList(ctx context.Context, a *app, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	addons, err := a.fetchCatalog()
+	if err != nil {
+		return err
+	}
+
+	return formatter.Write(a.stdout, formatter.Addons(addons), a.output)
+}
+
+func runSearch(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	term := strings.ToLower(strings.Join(args, " "))
+
+	addons, err := a.fetchCatalog()
+	if err != nil {
+		return err
+	}
+
+	var found []tukui.Addon
+	for _, addon := range addons {
+		for _, field := range []*string{addon.Name, addon.SmallDesc, addon.Author} {
+			if strings.Contains(strings.ToLower(value(field)), term) {
+				found = append(found, addon)
+				break
+			}
+		}
+	}
+
+	if len(found) == 0 {
+		return fmt.Errorf("%w: no addon matches %q", errNotFound, term)
+	}
+
+	return formatter.Write(a.stdout, formatter.Addons(found), a.output)
+}
+
+func runInfo(ctx context.Context, a *app, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	addon, err := a.lookup(args[0])
+	if err != nil {
+		return err
+	}
+
+	return a.printAddon(addon)
+}
+
+func runUI(ctx context.Context, a *app, args []string) error {
+	switch len(args) {
+	case 0:
+		uis, _, err := a.addons.ListUIs(ctx)
+		if err != nil {
+			return err
+		}
+
+		return formatter.Write(a.stdout, formatter.Addons(uis), a.output)
+	case 1:
+		addon, _, err := a.addons.GetUI(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		return a.printAddon(addon)
+	}
+
+	return errUsage
+}
+
+func runInstall(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	installer, err := a.installer()
+	if err != nil {
+		return err
+	}
+
+	return forEach(a, args, func(arg string) error {
+		addon, err := a.lookup(arg)
+		if err != nil {
+			return err
+		}
+
+		catalog, err := a.fetchCatalog()
+		if err != nil {
+			return err
+		}
+
+		entries, err := installer.InstallWithDependencies(ctx, addon, catalog)
+		for _, entry := range entries {
+			fmt.Fprintf(a.stdout, "installed %s %s\n", entry.Name, entry.Version)
+		}
+
+		return err
+	})
+}
+
+func runUpdate(ctx context.Context, a *app, args []string) error {
+	installer, err := a.installer()
+	if err != nil {
+		return err
+	}
+
+	manifest, err := installer.Manifest()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		for _, entry := range manifest.Addons {
+			if entry.Flavor == a.flavor {
+				args = append(args, entry.ID)
+			}
+		}
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintln(a.stdout, "no addons installed")
+		return nil
+	}
+
+	updated := 0
+	err = forEach(a, args, func(id string) error {
+		entry, ok := manifest.Entry(id)
+		if !ok || entry.Flavor != a.flavor {
+			return fmt.Errorf("%w: %s", tukui.ErrNotInstalled, id)
+		}
+
+		catalog, err := a.fetchCatalog()
+		if err != nil {
+			return err
+		}
+
+		var remote *tukui.Addon
+		for n := range catalog {
+			if value(catalog[n].Id) == id {
+				remote = &catalog[n]
+				break
+			}
+		}
+
+		if remote == nil {
+			return fmt.Errorf("%w: %s is not in the catalog anymore", errNotFound, entry.Name)
+		}
+
+		if !isOutdated(entry.Version, value(remote.Version)) {
+			return nil
+		}
+
+		if _, err := installer.Install(ctx, *remote); err != nil {
+			return err
+		}
+
+		updated++
+		fmt.Fprintf(a.stdout, "updated %s %s -> %s\n", entry.Name, entry.Version, value(remote.Version))
+
+		return nil
+	})
+
+	if err == nil && updated == 0 {
+		fmt.Fprintln(a.stdout, "all addons are up to date")
+	}
+
+	return err
+}
+
+// isOutdated reports whether the remote version is newer. Versions that cannot be
+// compared are outdated if they differ, as the catalog is always right.
+func isOutdated(local, remote string) bool {
+	c, err := version.CompareStrings(local, remote)
+	if err != nil {
+		return local != remote
+	}
+
+	return c < 0
+}
+
+func uninstallFlags(fs *flag.FlagSet, o *options) {
+	fs.BoolVar(&o.dryRun, "dry-run", false, "only print the folders that would be removed")
+}
+
+func runUninstall(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	installer, err := a.installer()
+	if err != nil {
+		return err
+	}
+
+	verb := "removed"
+	if a.dryRun {
+		verb = "would remove"
+	}
+
+	return forEach(a, args, func(id string) error {
+		result, err := installer.Uninstall(id, &tukui.UninstallOptions{DryRun: a.dryRun})
+		if err != nil {
+			return err
+		}
+
+		for _, folder := range result.Removed {
+			fmt.Fprintf(a.stdout, "%s %s\n", verb, folder)
+		}
+		for _, folder := range result.Kept {
+			fmt.Fprintf(a.stdout, "kept %s, it is used by another addon\n", folder)
+		}
+
+		return nil
+	})
+}
+
+func runOutdated(ctx context.Context, a *app, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	if a.addonsDir == "" {
+		return fmt.Errorf("%w: -addons-dir is required", errUsage)
+	}
+
+	result, err := tukui.Scan(a.addonsDir, a.flavor, a.addons)
+	if err != nil {
+		return err
+	}
+
+	for _, skipped := range result.Skipped {
+		fmt.Fprintf(a.stderr, "tukui: warning: skipping %v\n", skipped)
+	}
+
+	updates, err := a.client.CheckUpdates(result.Addons, a.flavor)
+	if err != nil {
+		return err
+	}
+
+	var outdated []tukui.Update
+	for _, u := range updates {
+		if u.Status == tukui.StatusOutdated {
+			outdated = append(outdated, u)
+		}
+	}
+
+	return formatter.Write(a.stdout, formatter.Updates(outdated), a.output)
+}
+
+// printAddon writes the details of a single addon, tables are rendered vertically.
+func (a *app) printAddon(addon tukui.Addon) error {
+	opts := *a.output
+	opts.Vertical = true
+
+	return formatter.Write(a.stdout, formatter.Addons([]tukui.Addon{addon}), &opts)
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
@@ -0,0 +1,245 @@
+// Command tukui browses the TukUI.org catalog and manages the addons of a game client.
+//
+// Usage:
+//
+//	tukui [flags] <command> [arguments]
+//
+// The commands are:
+//
+//	list                     list all addons of the catalog
+//	search <term>            search the catalog by name and description
+//	info <id|slug>           show the details of an addon
+//	ui [name]                show the details of a UI suite, or list them
+//	install <id|slug>...     install addons and their dependencies
+//	update [id...]           update installed addons to the catalog version
+//	uninstall <id>...        remove installed addons
+//	outdated                 list installed addons with a newer catalog version
+//
+// The legacy api.php is queried by default, -api v1 selects the v1 API of tukui.org.
+//
+// The output of list, search, info, ui and outdated can be rendered as table, json,
+// ndjson, csv or yaml with the -output flag and limited to some fields with -fields.
+//
+// The exit code is 0 on success, 1 for other errors, 2 for invalid usage, 3 for
+// network errors, 4 if an addon was not found and 5 if a command failed for some
+// of its arguments only.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/signal"
+
+	"github.com/unly/go-tukui"
+	"github.com/unly/go-tukui/formatter"
+)
+
+// Exit codes of the tukui command.
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitNetwork
+	exitNotFound
+	exitPartial
+)
+
+var (
+	errUsage    = errors.New("invalid usage")
+	errNotFound = errors.New("not found")
+)
+
+// options are the flags shared by all commands.
+type options struct {
+	flavor    string
+	addonsDir string
+	api       string
+	apiURL    string
+	output    string
+	fields    string
+	dryRun    bool
+}
+
+// register adds the shared flags to fs with the current values as defaults, so
+// they can be given before and after the command.
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.flavor, "flavor", o.flavor, "game client `flavor`: retail or classic")
+	fs.StringVar(&o.addonsDir, "addons-dir", o.addonsDir, "Interface/AddOns `directory` of the game client")
+	fs.StringVar(&o.api, "api", o.api, "`version` of the TukUI API: legacy or v1")
+	fs.StringVar(&o.apiURL, "api-url", o.apiURL, "`url` of the TukUI API")
+	fs.StringVar(&o.output, "output", o.output, "output `format`: table, json, ndjson, csv or yaml")
+	fs.StringVar(&o.fields, "fields", o.fields, "comma separated `list` of fields to output, e.g. id,name,version")
+}
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, app *app, args []string) error
+	flags func(fs *flag.FlagSet, o *options)
+}
+
+var commands = map[string]command{
+	"list":      {usage: "list", run: runList},
+	"search":    {usage: "search <term>", run: runSearch},
+	"info":      {usage: "info <id|slug>", run: runInfo},
+	"ui":        {usage: "ui [name]", run: runUI},
+	"install":   {usage: "install <id|slug>...", run: runInstall},
+	"update":    {usage: "update [id...]", run: runUpdate},
+	"uninstall": {usage: "uninstall [-dry-run] <id>...", run: runUninstall, flags: uninstallFlags},
+	"outdated":  {usage: "outdated", run: runOutdated},
+}
+
+var commandOrder = []string{"list", "search", "info", "ui", "install", "update", "uninstall", "outdated"}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	opts := options{flavor: string(tukui.FlavorRetail), api: tukui.APILegacy.String(), output: string(formatter.Table)}
+
+	root := flag.NewFlagSet("tukui", flag.ContinueOnError)
+	root.SetOutput(stderr)
+	root.Usage = func() { usage(root, stderr) }
+	opts.register(root)
+
+	if err := root.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if root.NArg() == 0 {
+		usage(root, stderr)
+		return exitUsage
+	}
+
+	name := root.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "tukui: unknown command %q\n", name)
+		usage(root, stderr)
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("tukui "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: tukui %s\n", cmd.usage)
+		fs.PrintDefaults()
+	}
+	opts.register(fs)
+	if cmd.flags != nil {
+		cmd.flags(fs, &opts)
+	}
+
+	if err := fs.Parse(root.Args()[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	app, err := newApp(opts, stdout, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "tukui: %v\n", err)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err = cmd.run(ctx, app, fs.Args())
+
+	var reported reportedError
+	switch {
+	case errors.Is(err, errUsage):
+		fs.Usage()
+	case errors.As(err, &reported):
+	case err != nil:
+		fmt.Fprintf(stderr, "tukui: %v\n", err)
+	}
+
+	return exitCode(err)
+}
+
+func usage(fs *flag.FlagSet, w io.Writer) {
+	fmt.Fprintln(w, "usage: tukui [flags] <command> [arguments]")
+	fmt.Fprintln(w, "\ncommands:")
+	for _, name := range commandOrder {
+		fmt.Fprintf(w, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(w, "\nflags:")
+	fs.PrintDefaults()
+}
+
+// exitCode maps an error returned by a command to the exit code.
+func exitCode(err error) int {
+	var partial *partialError
+	var urlErr *url.Error
+	var netErr net.Error
+
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &partial):
+		return exitPartial
+	case errors.Is(err, errUsage), errors.Is(err, tukui.ErrUnsupportedFlavor), errors.Is(err, tukui.ErrSlugCollision), errors.Is(err, formatter.ErrUnknownField):
+		return exitUsage
+	case errors.As(err, &urlErr), errors.As(err, &netErr):
+		return exitNetwork
+	case errors.Is(err, errNotFound), errors.Is(err, tukui.ErrEmptyResponse), errors.Is(err, tukui.ErrUnknownUI), errors.Is(err, tukui.ErrNotInstalled):
+		return exitNotFound
+	}
+
+	return exitError
+}
+
+// partialError is returned by commands that succeeded for some arguments only.
+type partialError struct {
+	failed, total int
+}
+
+func (e *partialError) Error() string {
+	return fmt.Sprintf("%d of %d failed", e.failed, e.total)
+}
+
+// forEach calls fn for every argument and reports the failures on stderr. If all
+// calls fail, the last error is returned, if some fail a *partialError.
+func forEach(app *app, args []string, fn func(arg string) error) error {
+	var last error
+	failed := 0
+
+	for _, arg := range args {
+		if err := fn(arg); err != nil {
+			fmt.Fprintf(app.stderr, "tukui: %s: %v\n", arg, err)
+			failed++
+			last = err
+		}
+	}
+
+	switch {
+	case failed == 0:
+		return nil
+	case failed == len(args):
+		return reportedError{last}
+	}
+
+	return &partialError{failed: failed, total: len(args)}
+}
+
+// reportedError is an error that was already written to stderr.
+type reportedError struct {
+	err error
+}
+
+func (e reportedError) Error() string { return e.err.Error() }
+
+func (e reportedError) Unwrap() error { return e.err }
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeAPI serves the catalog of the retail API and the .zip files of the addons.
+type fakeAPI struct {
+	*httptest.Server
+	version string
+}
+
+func newFakeAPI(t *testing.T) *fakeAPI {
+	api := &fakeAPI{version: "3.53"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api.php", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case query.Get("addons") == "all":
+			fmt.Fprintf(w, `[{"id": "3", "name": "AddOnSkins", "version": %q, "url": "%s/download/AddOnSkins", "small_desc": "Skins for AddOns"}]`, api.version, api.URL)
+		case query.Get("addon") == "3":
+			fmt.Fprintf(w, `{"id": "3", "name": "AddOnSkins", "version": %q, "url": "%s/download/AddOnSkins"}`, api.version, api.URL)
+		case query.Get("ui") == "elvui":
+			fmt.Fprintf(w, `{"id": -2, "name": "ElvUI", "version": "13.64", "url": "%s/download/ElvUI"}`, api.URL)
+		case query.Get("ui") == "tukui":
+			fmt.Fprintf(w, `{"id": -1, "name": "Tukui", "version": "20.38", "url": "%s/download/Tukui"}`, api.URL)
+		default:
+			// unknown addons have an empty response
+		}
+	})
+	mux.HandleFunc("/v1/addons", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"id": -2, "slug": "elvui", "name": "ElvUI", "version": "13.64", "url": "%s/download/ElvUI", "patch": ["10.1.5", "1.14.4"]}]`, api.URL)
+	})
+	mux.HandleFunc("/v1/addon/elvui", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id": -2, "slug": "elvui", "name": "ElvUI", "version": "13.64", "url": "%s/download/ElvUI", "patch": ["10.1.5", "1.14.4"]}`, api.URL)
+	})
+	mux.HandleFunc("/v1/addon/tukui", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id": -1, "slug": "tukui", "name": "Tukui", "version": "20.38", "url": "%s/download/Tukui", "patch": ["10.1.5"]}`, api.URL)
+	})
+	mux.HandleFunc("/download/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/download/")
+		w.Header().Set("Content-Type", "application/zip")
+		w.Write(addonZip(t, name, api.version))
+	})
+
+	api.Server = httptest.NewServer(mux)
+	t.Cleanup(api.Close)
+
+	return api
+}
+
+func addonZip(t *testing.T, folder, version string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	w, err := zw.Create(folder + "/" + folder + ".toc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(w, "## Title: %s\n## Version: %s\n", folder, version)
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func runCommand(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Catalog(t *testing.T) {
+	api := newFakeAPI(t)
+	apiURL := "--api-url=" + api.URL + "/api.php"
+
+	tests := []struct {
+		args   []string
+		code   int
+		output string
+	}{
+		{[]string{apiURL, "list"}, exitOK, "AddOnSkins"},
+		{[]string{"list", apiURL}, exitOK, "Tukui"},
+		{[]string{apiURL, "search", "skins"}, exitOK, "AddOnSkins"},
+		{[]string{apiURL, "search", "weakauras"}, exitNotFound, ""},
+		{[]string{apiURL, "info", "3"}, exitOK, "version:  3.53"},
+		{[]string{apiURL, "info", "99"}, exitNotFound, ""},
+		{[]string{apiURL, "info", "abc"}, exitNotFound, ""},
+		{[]string{apiURL, "info", "addonskins"}, exitOK, "AddOnSkins"},
+		{[]string{apiURL, "ui", "elvui"}, exitOK, "name:     ElvUI"},
+		{[]string{apiURL, "ui", "gw2"}, exitNotFound, ""},
+		{[]string{apiURL, "ui"}, exitOK, "Tukui"},
+		{[]string{apiURL, "--flavor", "wrath", "list"}, exitUsage, ""},
+		{[]string{apiURL, "install", "3"}, exitUsage, ""},
+		{[]string{apiURL, "unknown"}, exitUsage, ""},
+		{[]string{apiURL, "--output", "csv", "--fields", "id,version", "list"}, exitOK, "id,version\r\n3,3.53\r\n"},
+		{[]string{apiURL, "list", "--output", "ndjson", "--fields", "name"}, exitOK, `{"name":"ElvUI"}`},
+		{[]string{apiURL, "--output", "yaml", "info", "3"}, exitOK, "  version: \"3.53\"\n"},
+		{[]string{apiURL, "--fields", "title", "list"}, exitUsage, ""},
+		{[]string{apiURL, "--output", "xml", "list"}, exitUsage, ""},
+		{[]string{"--api-url=http://127.0.0.1:1/api.php", "list"}, exitNetwork, ""},
+	}
+
+	for _, tt := range tests {
+		code, stdout, stderr := runCommand(t, tt.args...)
+		if code != tt.code {
+			t.Errorf("run(%v) returned %d, want %d; stderr: %s", tt.args, code, tt.code, stderr)
+		}
+
+		if !strings.Contains(stdout, tt.output) {
+			t.Errorf("run(%v) printed %q, want %q", tt.args, stdout, tt.output)
+		}
+	}
+}
+
+func TestRun_APIVersion(t *testing.T) {
+	api := newFakeAPI(t)
+	apiURL := "--api-url=" + api.URL + "/v1"
+
+	tests := []struct {
+		args   []string
+		code   int
+		output string
+	}{
+		{[]string{"--api", "v1", apiURL, "--flavor", "classic", "ui", "elvui"}, exitOK, "patch:    1.14.4"},
+		{[]string{"--api", "v1", apiURL, "--flavor", "classic", "ui", "tukui"}, exitNotFound, ""},
+		{[]string{"--api", "v1", apiURL, "ui", "tukui"}, exitOK, "version:  20.38"},
+		{[]string{apiURL, "list", "--api", "v1", "--fields", "slug"}, exitOK, "elvui"},
+		{[]string{"--api", "v2", apiURL, "list"}, exitUsage, ""},
+	}
+
+	for _, tt := range tests {
+		code, stdout, stderr := runCommand(t, tt.args...)
+		if code != tt.code {
+			t.Errorf("run(%v) returned %d, want %d; stderr: %s", tt.args, code, tt.code, stderr)
+		}
+
+		if !strings.Contains(stdout, tt.output) {
+			t.Errorf("run(%v) printed %q, want %q", tt.args, stdout, tt.output)
+		}
+	}
+}
+
+func TestRun_Manage(t *testing.T) {
+	api := newFakeAPI(t)
+
+	dir, err := ioutil.TempDir("", "tukui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	addonsDir := filepath.Join(dir, "Interface", "AddOns")
+	flags := []string{"--api-url", api.URL + "/api.php", "--addons-dir", addonsDir}
+
+	steps := []struct {
+		args   []string
+		code   int
+		output string
+	}{
+		{[]string{"install", "3", "elvui", "99"}, exitPartial, "installed ElvUI 13.64"},
+		{[]string{"update"}, exitOK, "all addons are up to date"},
+		{[]string{"outdated"}, exitOK, "NAME"},
+		{[]string{"uninstall", "-dry-run", "3"}, exitOK, "would remove AddOnSkins"},
+		{[]string{"uninstall", "-2", "3"}, exitUsage, ""},
+		{[]string{"uninstall", "99"}, exitNotFound, ""},
+	}
+
+	for _, step := range steps {
+		code, stdout, stderr := runCommand(t, append(flags, step.args...)...)
+		if code != step.code {
+			t.Errorf("run(%v) returned %d, want %d; stderr: %s", step.args, code, step.code, stderr)
+		}
+
+		if !strings.Contains(stdout, step.output) {
+			t.Errorf("run(%v) printed %q, want %q", step.args, stdout, step.output)
+		}
+	}
+
+	api.version = "3.54"
+
+	code, stdout, _ := runCommand(t, append(flags, "outdated")...)
+	if code != exitOK || !strings.Contains(stdout, "AddOnSkins  3.53       3.54") {
+		t.Errorf("run(outdated) returned %d, printed %q", code, stdout)
+	}
+
+	code, stdout, _ = runCommand(t, append(flags, "update")...)
+	if code != exitOK || stdout != "updated AddOnSkins 3.53 -> 3.54\n" {
+		t.Errorf("run(update) returned %d, printed %q", code, stdout)
+	}
+
+	code, stdout, _ = runCommand(t, append(flags, "uninstall", "--", "3", "-2")...)
+	if code != exitOK || stdout != "removed AddOnSkins\nremoved ElvUI\n" {
+		t.Errorf("run(uninstall) returned %d, printed %q", code, stdout)
+	}
+
+	if entries, _ := ioutil.ReadDir(addonsDir); len(entries) != 0 {
+		t.Errorf("run(uninstall) left %d folders", len(entries))
+	}
+}
@@ -0,0 +1,221 @@
+package tukui
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// ErrNoDownloadURL is returned when an Addon has no URL to download the .zip file from.
+var ErrNoDownloadURL = errors.New("addon has no download url")
+
+// DownloadOptions configures a call to Client.Download. A nil pointer is valid
+// and equals the zero value.
+type DownloadOptions struct {
+	// Progress is called every time a chunk was written. written is the number of
+	// bytes written so far and total the expected size, or -1 if it is unknown.
+	Progress func(written, total int64)
+	// Store is checked before anything is downloaded. If it holds the .zip file for the
+	// ID and version of the Addon no request is made at all. Otherwise the downloaded
+	// file is added to the Store.
+	Store *Store
+}
+
+// DownloadResult describes the data written by a successful download.
+type DownloadResult struct {
+	// Size is the number of bytes written
+	Size int64
+	// SHA256 is the hex encoded SHA-256 checksum of the bytes written
+	SHA256 string
+	// Cached is true if the file was served from DownloadOptions.Store
+	Cached bool
+}
+
+// Download fetches the .zip file of the given Addon and writes it to w. It uses the
+// http.Client the Client was created with and verifies that the server answered
+// with a zip archive.
+func (c *Client) Download(ctx context.Context, addon Addon, w io.Writer, opts *DownloadOptions) (DownloadResult, *http.Response, error) {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+
+	if result, ok, err := fromStore(opts.Store, addon, w, opts.Progress); ok || err != nil {
+		return result, nil, err
+	}
+
+	resp, err := c.requestDownload(ctx, addon, nil)
+	if err != nil {
+		return DownloadResult{}, resp, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return DownloadResult{}, resp, unexpectedStatus(resp)
+	}
+
+	if err := checkZipContentType(resp); err != nil {
+		return DownloadResult{}, resp, err
+	}
+
+	var sw *storeWriter
+	if id, version, ok := storeKey(addon); ok && opts.Store != nil {
+		sw, err = opts.Store.newWriter(id, version)
+		if err != nil {
+			return DownloadResult{}, resp, err
+		}
+		w = io.MultiWriter(w, sw)
+	}
+
+	h := sha256.New()
+	n, err := copyWithProgress(io.MultiWriter(w, h), resp.Body, 0, resp.ContentLength, opts.Progress)
+	if sw != nil {
+		if err != nil {
+			sw.abort()
+		} else {
+			_, err = sw.commit()
+		}
+	}
+
+	return DownloadResult{Size: n, SHA256: hexSum(h)}, resp, err
+}
+
+// storeKey returns the ID and version a Store uses to reference the .zip file of the addon.
+func storeKey(addon Addon) (string, string, bool) {
+	if addon.Id == nil || !validStoreKey(*addon.Id) || addon.Version == nil || !validStoreKey(*addon.Version) {
+		return "", "", false
+	}
+
+	return *addon.Id, *addon.Version, true
+}
+
+// fromStore copies the stored .zip file of the addon to w. ok is false if the
+// file is not available from the store and needs to be downloaded.
+func fromStore(store *Store, addon Addon, w io.Writer, progress func(written, total int64)) (result DownloadResult, ok bool, err error) {
+	id, version, ok := storeKey(addon)
+	if store == nil || !ok {
+		return DownloadResult{}, false, nil
+	}
+
+	r, entry, err := store.Open(id, version)
+	if errors.Is(err, ErrNotStored) || errors.Is(err, ErrChecksumMismatch) {
+		return DownloadResult{}, false, nil
+	}
+
+	if err != nil {
+		return DownloadResult{}, false, err
+	}
+	defer r.Close()
+
+	n, err := copyWithProgress(w, r, 0, entry.Size, progress)
+	if err != nil {
+		return DownloadResult{}, false, err
+	}
+
+	return DownloadResult{Size: n, SHA256: entry.SHA256, Cached: true}, true, nil
+}
+
+func (c *Client) requestDownload(ctx context.Context, addon Addon, header http.Header) (*http.Response, error) {
+	if addon.URL == nil || *addon.URL == "" {
+		return nil, ErrNoDownloadURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *addon.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	return c.httpClient.Do(req)
+}
+
+func unexpectedStatus(resp *http.Response) error {
+	return fmt.Errorf("unexpected status: %s", resp.Status)
+}
+
+// zipContentTypes are the media types servers use to deliver zip archives
+var zipContentTypes = map[string]bool{
+	"application/zip":              true,
+	"application/x-zip":            true,
+	"application/x-zip-compressed": true,
+	"application/octet-stream":     true,
+}
+
+func checkZipContentType(resp *http.Response) error {
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+
+	if !zipContentTypes[mediaType] {
+		return fmt.Errorf("unexpected content type %q", mediaType)
+	}
+
+	return nil
+}
+
+// copyWithProgress copies src to dst and reports the number of bytes written,
+// starting at offset, to progress after every chunk.
+func copyWithProgress(dst io.Writer, src io.Reader, offset, total int64, progress func(written, total int64)) (int64, error) {
+	if total >= 0 {
+		total += offset
+	}
+
+	if total < 0 {
+		total = -1
+	}
+
+	buf := make([]byte, 32*1024)
+	written := offset
+
+	for {
+		nr, rerr := src.Read(buf)
+		if nr > 0 {
+			nw, werr := dst.Write(buf[:nr])
+			written += int64(nw)
+			if progress != nil {
+				progress(written, total)
+			}
+
+			if werr != nil {
+				return written - offset, werr
+			}
+
+			if nw != nr {
+				return written - offset, io.ErrShortWrite
+			}
+		}
+
+		if rerr == io.EOF {
+			break
+		}
+
+		if rerr != nil {
+			return written - offset, rerr
+		}
+	}
+
+	if total >= 0 && written != total {
+		return written - offset, io.ErrUnexpectedEOF
+	}
+
+	return written - offset, nil
+}
+
+func hexSum(h hash.Hash) string {
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,148 @@
+package tukui
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestClient_Download(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	payload := bytes.Repeat([]byte("tukui"), 20000)
+
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		testHTTPMethod(t, r, http.MethodGet)
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Length", fmt.Sprint(len(payload)))
+		w.Write(payload)
+	})
+
+	var calls int
+	var last, total int64
+	opts := &DownloadOptions{
+		Progress: func(w, t int64) {
+			calls++
+			last, total = w, t
+		},
+	}
+
+	var buf bytes.Buffer
+	addon := Addon{URL: String(client.url + "download")}
+	result, _, err := client.Download(context.Background(), addon, &buf, opts)
+	if err != nil {
+		t.Fatalf("Client.Download() returned error: %v", err)
+	}
+
+	want := DownloadResult{Size: int64(len(payload)), SHA256: sha256Hex(payload)}
+	if !cmp.Equal(result, want) {
+		t.Errorf("Client.Download() returned %+v, want %+v", result, want)
+	}
+
+	if !bytes.Equal(buf.Bytes(), payload) {
+		t.Errorf("Client.Download() wrote %d bytes, want %d", buf.Len(), len(payload))
+	}
+
+	if calls == 0 || last != int64(len(payload)) || total != int64(len(payload)) {
+		t.Errorf("Progress called %d times with %d/%d, want %d/%d", calls, last, total, len(payload), len(payload))
+	}
+}
+
+func TestClient_Download_NoURL(t *testing.T) {
+	client := NewClient(nil)
+
+	_, _, err := client.Download(context.Background(), Addon{}, &bytes.Buffer{}, nil)
+	if !errors.Is(err, ErrNoDownloadURL) {
+		t.Errorf("Client.Download() returned %v, want %v", err, ErrNoDownloadURL)
+	}
+}
+
+func TestClient_Download_BadStatus(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+
+	addon := Addon{URL: String(client.url + "download")}
+	_, resp, err := client.Download(context.Background(), addon, &bytes.Buffer{}, nil)
+	if err == nil {
+		t.Errorf("Client.Download() returned no error")
+	}
+
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Client.Download() returned response %v, want status %d", resp, http.StatusNotFound)
+	}
+}
+
+func TestClient_Download_HTMLPage(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<html><body>maintenance</body></html>")
+	})
+
+	var buf bytes.Buffer
+	addon := Addon{URL: String(client.url + "download")}
+	_, _, err := client.Download(context.Background(), addon, &buf, nil)
+	want := `unexpected content type "text/html"`
+	if err == nil || err.Error() != want {
+		t.Errorf("Client.Download() returned %v, want %v", err, want)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("Client.Download() wrote %d bytes, want 0", buf.Len())
+	}
+}
+
+func TestClient_Download_Truncated(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte("short"))
+	})
+
+	addon := Addon{URL: String(client.url + "download")}
+	_, _, err := client.Download(context.Background(), addon, &bytes.Buffer{}, nil)
+	if err == nil {
+		t.Errorf("Client.Download() returned no error")
+	}
+}
+
+func TestClient_Download_Canceled(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Write([]byte("PK"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	addon := Addon{URL: String(client.url + "download")}
+	_, _, err := client.Download(ctx, addon, &bytes.Buffer{}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Client.Download() returned %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,411 @@
+package tukui
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultMaxSize is the default limit for the uncompressed size of an addon .zip file
+	DefaultMaxSize = 1 << 30
+	// DefaultMaxFiles is the default limit for the number of files in an addon .zip file
+	DefaultMaxFiles = 20000
+)
+
+const (
+	stagingPrefix  = ".tukui-staging-"
+	replacedPrefix = ".tukui-replaced-"
+	obsoletePrefix = ".tukui-obsolete-"
+	removedPrefix  = ".tukui-removed-"
+)
+
+var (
+	// ErrUnsafePath is returned for zip entries that would be written outside of the target directory.
+	ErrUnsafePath = errors.New("unsafe path in zip file")
+	// ErrTooLarge is returned if the uncompressed content of a zip file exceeds the size limit.
+	ErrTooLarge = errors.New("zip file content exceeds size limit")
+	// ErrTooManyFiles is returned if a zip file contains more files than allowed.
+	ErrTooManyFiles = errors.New("zip file contains too many files")
+	// ErrNoAddonFolder is returned for zip files with files outside of a top-level addon folder.
+	ErrNoAddonFolder = errors.New("zip file content is not in an addon folder")
+)
+
+// ExtractOptions limits the content accepted by InstallZip. A nil pointer is valid
+// and uses the default limits.
+type ExtractOptions struct {
+	// MaxSize is the maximum total uncompressed size in bytes. Zero means DefaultMaxSize.
+	MaxSize int64
+	// MaxFiles is the maximum number of files. Zero means DefaultMaxFiles.
+	MaxFiles int
+}
+
+func (o *ExtractOptions) limits() (int64, int) {
+	maxSize, maxFiles := int64(DefaultMaxSize), DefaultMaxFiles
+	if o != nil && o.MaxSize > 0 {
+		maxSize = o.MaxSize
+	}
+
+	if o != nil && o.MaxFiles > 0 {
+		maxFiles = o.MaxFiles
+	}
+
+	return maxSize, maxFiles
+}
+
+// InstallZip extracts the addon .zip file at zipPath into addonsDir, usually an
+// Interface/AddOns directory, and returns the names of the installed top-level folders.
+//
+// Addon .zip files contain one or more top-level folders, e.g. ElvUI and ElvUI_Options,
+// which are installed as they are. Existing folders with the same names are replaced.
+// Entries with absolute paths, paths leaving the folder, symlinks and files outside of
+// a top-level folder are rejected.
+//
+// The content is extracted into a staging directory inside addonsDir first and only
+// moved into place once everything was extracted, so an interrupted installation does
+// not leave a partially extracted addon behind. The leftovers of an interrupted
+// installation are cleaned up by the next one.
+//
+// Calls for the same addonsDir are serialized within a process. Only one process may
+// change an AddOns directory at a time, otherwise an installation could clean up the
+// staging directory of another one.
+func InstallZip(zipPath, addonsDir string, opts *ExtractOptions) ([]string, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return installZip(&r.Reader, addonsDir, opts)
+}
+
+func installZip(r *zip.Reader, addonsDir string, opts *ExtractOptions) ([]string, error) {
+	if err := os.MkdirAll(addonsDir, 0755); err != nil {
+		return nil, err
+	}
+
+	unlock := lockAddonsDir(addonsDir)
+	defer unlock()
+
+	removeStaleStaging(addonsDir)
+
+	staging, err := ioutil.TempDir(addonsDir, stagingPrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	folders, err := extractZip(r, staging, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := replaceFolders(staging, addonsDir, folders); err != nil {
+		return nil, err
+	}
+
+	return folders, nil
+}
+
+// addonsDirLocks holds a *sync.Mutex for every AddOns directory changed by the process
+var addonsDirLocks sync.Map
+
+// lockAddonsDir locks the AddOns directory against changes by other goroutines and
+// returns the function to unlock it.
+func lockAddonsDir(addonsDir string) func() {
+	key := filepath.Clean(addonsDir)
+	if abs, err := filepath.Abs(key); err == nil {
+		key = abs
+	}
+
+	mu, _ := addonsDirLocks.LoadOrStore(key, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+
+	return mu.(*sync.Mutex).Unlock
+}
+
+// extractZip writes the content of r into dir after validating every entry. It
+// returns the sorted names of the top-level folders.
+func extractZip(r *zip.Reader, dir string, opts *ExtractOptions) ([]string, error) {
+	maxSize, maxFiles := opts.limits()
+
+	var files int
+	remaining := maxSize
+	folders := make(map[string]bool)
+
+	for _, f := range r.File {
+		name, err := zipEntryName(f)
+		if err != nil {
+			return nil, err
+		}
+
+		if name == "" {
+			continue
+		}
+
+		isDir := f.FileInfo().IsDir()
+		top := strings.SplitN(name, "/", 2)[0]
+		if !isDir && top == name {
+			return nil, fmt.Errorf("%w: %s", ErrNoAddonFolder, f.Name)
+		}
+
+		folders[top] = true
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		if isDir {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		files++
+		if files > maxFiles {
+			return nil, ErrTooManyFiles
+		}
+
+		n, err := extractFile(f, target, remaining)
+		if err != nil {
+			return nil, err
+		}
+		remaining -= n
+	}
+
+	if len(folders) == 0 {
+		return nil, ErrNoAddonFolder
+	}
+
+	names := make([]string, 0, len(folders))
+	for folder := range folders {
+		names = append(names, folder)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// zipEntryName validates the name of a zip entry and returns it as a clean slash
+// separated relative path. Entries that should be skipped return an empty name.
+func zipEntryName(f *zip.File) (string, error) {
+	mode := f.Mode()
+	if mode&os.ModeSymlink != 0 || !(mode.IsRegular() || mode.IsDir()) {
+		return "", fmt.Errorf("%w: %s is not a regular file", ErrUnsafePath, f.Name)
+	}
+
+	// some archivers on Windows use backslashes as separator
+	name := strings.ReplaceAll(f.Name, `\`, "/")
+	if strings.HasPrefix(name, "/") || filepath.VolumeName(name) != "" || strings.Contains(name, ":") {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, f.Name)
+	}
+
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("%w: %s", ErrUnsafePath, f.Name)
+		}
+	}
+
+	name = path.Clean(name)
+	if name == "." || strings.HasPrefix(name, "__MACOSX/") || name == "__MACOSX" {
+		return "", nil
+	}
+
+	if strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, f.Name)
+	}
+
+	return name, nil
+}
+
+// extractFile writes the content of f to target. Reading stops with ErrTooLarge as
+// soon as more than limit bytes were extracted, regardless of the sizes stated in
+// the zip headers.
+func extractFile(f *zip.File, target string, limit int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.Copy(out, io.LimitReader(rc, limit+1))
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		return n, err
+	}
+
+	if n > limit {
+		return n, ErrTooLarge
+	}
+
+	return n, nil
+}
+
+// journalName is the file in the replaced directory listing the planned swaps of an
+// installation, or in the removed directory of an uninstallation. Addon folders
+// cannot start with a dot, so it never collides with one.
+const journalName = ".journal.json"
+
+// A swap replaces a folder of the AddOns directory with the staged one.
+type swap struct {
+	Folder string `json:"folder"`
+	// Existed is set if the folder was moved aside into the replaced directory
+	Existed bool `json:"existed"`
+}
+
+// replaceFolders moves the given folders from staging into addonsDir. Existing
+// folders are moved aside first. The planned swaps are written to a journal before
+// any folder is moved, so either all folders are replaced or, after a failure or a
+// crash, none of them.
+func replaceFolders(staging, addonsDir string, folders []string) error {
+	replaced, err := ioutil.TempDir(addonsDir, replacedPrefix)
+	if err != nil {
+		return err
+	}
+
+	swaps := make([]swap, len(folders))
+	for i, folder := range folders {
+		_, err := os.Lstat(filepath.Join(addonsDir, folder))
+		swaps[i] = swap{Folder: folder, Existed: err == nil}
+	}
+
+	if err := writeJournal(replaced, swaps); err != nil {
+		os.RemoveAll(replaced)
+		return err
+	}
+
+	for _, s := range swaps {
+		dest := filepath.Join(addonsDir, s.Folder)
+
+		if s.Existed {
+			if err := os.Rename(dest, filepath.Join(replaced, s.Folder)); err != nil {
+				undoSwaps(addonsDir, replaced, swaps)
+				os.RemoveAll(replaced)
+				return err
+			}
+		}
+
+		if err := os.Rename(filepath.Join(staging, s.Folder), dest); err != nil {
+			undoSwaps(addonsDir, replaced, swaps)
+			os.RemoveAll(replaced)
+			return err
+		}
+	}
+
+	// renaming is atomic, a crash while deleting the old folders must not roll back
+	// the completed installation
+	obsolete := filepath.Join(addonsDir, obsoletePrefix+strings.TrimPrefix(filepath.Base(replaced), replacedPrefix))
+	if err := os.Rename(replaced, obsolete); err != nil {
+		return err
+	}
+
+	os.RemoveAll(obsolete)
+
+	return nil
+}
+
+func writeJournal(dir string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(dir, journalName))
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// undoSwaps restores the state before the swaps, whichever of them were done.
+// Folders moved aside replace the new ones and folders that did not exist before
+// are deleted.
+func undoSwaps(addonsDir, replaced string, swaps []swap) {
+	for i := len(swaps) - 1; i >= 0; i-- {
+		dest := filepath.Join(addonsDir, swaps[i].Folder)
+		old := filepath.Join(replaced, swaps[i].Folder)
+
+		if !swaps[i].Existed {
+			os.RemoveAll(dest)
+			continue
+		}
+
+		if _, err := os.Lstat(old); err == nil {
+			os.RemoveAll(dest)
+			os.Rename(old, dest)
+		}
+	}
+}
+
+// removeStaleStaging deletes the leftovers of interrupted installations. The swaps
+// of an interrupted installation are undone as a whole. Without a journal, every
+// folder that was moved aside is restored. Interrupted uninstallations are finished
+// or rolled back by recoverRemoval.
+func removeStaleStaging(addonsDir string) {
+	entries, err := ioutil.ReadDir(addonsDir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		dir := filepath.Join(addonsDir, entry.Name())
+
+		switch {
+		case strings.HasPrefix(entry.Name(), replacedPrefix):
+			var swaps []swap
+			if err := readJournal(dir, &swaps); err != nil {
+				swaps = nil
+				folders, _ := ioutil.ReadDir(dir)
+				for _, folder := range folders {
+					swaps = append(swaps, swap{Folder: folder.Name(), Existed: true})
+				}
+			}
+
+			undoSwaps(addonsDir, dir, swaps)
+			os.RemoveAll(dir)
+		case strings.HasPrefix(entry.Name(), removedPrefix):
+			recoverRemoval(addonsDir, dir)
+		case strings.HasPrefix(entry.Name(), stagingPrefix), strings.HasPrefix(entry.Name(), obsoletePrefix):
+			os.RemoveAll(dir)
+		}
+	}
+}
+
+func readJournal(dir string, v interface{}) error {
+	data, err := ioutil.ReadFile(filepath.Join(dir, journalName))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,359 @@
+package tukui
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type zipEntry struct {
+	name    string
+	content string
+	mode    os.FileMode
+}
+
+// writeZip creates a zip file with the given entries in dir and returns its path
+func writeZip(t *testing.T, dir string, entries ...zipEntry) string {
+	t.Helper()
+	f, err := ioutil.TempFile(dir, "addon-*.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(buildZip(t, entries...)); err != nil {
+		t.Fatal(err)
+	}
+
+	return f.Name()
+}
+
+// buildZip returns a zip file with the given entries
+func buildZip(t *testing.T, entries ...zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		if e.mode != 0 {
+			header.SetMode(e.mode)
+		}
+
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// listFiles returns all files below dir as slash separated relative paths
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(files)
+
+	return files
+}
+
+func TestInstallZip(t *testing.T) {
+	dir := tempDir(t)
+	addonsDir := filepath.Join(dir, "Interface", "AddOns")
+
+	zipPath := writeZip(t, dir,
+		zipEntry{name: "ElvUI/", mode: os.ModeDir | 0755},
+		zipEntry{name: "ElvUI/ElvUI.toc", content: "## Title: ElvUI"},
+		zipEntry{name: "ElvUI/Core/Core.lua", content: "local E"},
+		zipEntry{name: "ElvUI_Options/ElvUI_Options.toc", content: "## Title: ElvUI Options"},
+		zipEntry{name: `ElvUI_Libraries\ElvUI_Libraries.toc`, content: "## Title: ElvUI Libraries"},
+		zipEntry{name: "__MACOSX/ElvUI/._ElvUI.toc", content: "junk"},
+	)
+
+	folders, err := InstallZip(zipPath, addonsDir, nil)
+	if err != nil {
+		t.Fatalf("InstallZip() returned error: %v", err)
+	}
+
+	want := []string{"ElvUI", "ElvUI_Libraries", "ElvUI_Options"}
+	if !cmp.Equal(folders, want) {
+		t.Errorf("InstallZip() returned %v, want %v", folders, want)
+	}
+
+	wantFiles := []string{
+		"ElvUI/Core/Core.lua",
+		"ElvUI/ElvUI.toc",
+		"ElvUI_Libraries/ElvUI_Libraries.toc",
+		"ElvUI_Options/ElvUI_Options.toc",
+	}
+	if got := listFiles(t, addonsDir); !cmp.Equal(got, wantFiles) {
+		t.Errorf("InstallZip() created %v, want %v", got, wantFiles)
+	}
+}
+
+func TestInstallZip_ReplacesExisting(t *testing.T) {
+	dir := tempDir(t)
+	addonsDir := filepath.Join(dir, "AddOns")
+
+	for _, name := range []string{"ElvUI/Old.lua", "Details/Details.toc"} {
+		path := filepath.Join(addonsDir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte("old"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	zipPath := writeZip(t, dir, zipEntry{name: "ElvUI/ElvUI.toc", content: "new"})
+
+	if _, err := InstallZip(zipPath, addonsDir, nil); err != nil {
+		t.Fatalf("InstallZip() returned error: %v", err)
+	}
+
+	want := []string{"Details/Details.toc", "ElvUI/ElvUI.toc"}
+	if got := listFiles(t, addonsDir); !cmp.Equal(got, want) {
+		t.Errorf("InstallZip() left %v, want %v", got, want)
+	}
+}
+
+func TestInstallZip_Rejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []zipEntry
+		opts    *ExtractOptions
+		want    error
+	}{
+		{
+			name:    "zip slip",
+			entries: []zipEntry{{name: "ElvUI/../../evil.lua", content: "x"}},
+			want:    ErrUnsafePath,
+		},
+		{
+			name:    "zip slip with backslashes",
+			entries: []zipEntry{{name: `ElvUI\..\..\evil.lua`, content: "x"}},
+			want:    ErrUnsafePath,
+		},
+		{
+			name:    "absolute path",
+			entries: []zipEntry{{name: "/etc/evil.lua", content: "x"}},
+			want:    ErrUnsafePath,
+		},
+		{
+			name:    "drive letter",
+			entries: []zipEntry{{name: "C:/evil.lua", content: "x"}},
+			want:    ErrUnsafePath,
+		},
+		{
+			name:    "symlink",
+			entries: []zipEntry{{name: "ElvUI/link", content: "/etc/passwd", mode: os.ModeSymlink | 0777}},
+			want:    ErrUnsafePath,
+		},
+		{
+			name:    "loose file",
+			entries: []zipEntry{{name: "ElvUI.toc", content: "x"}},
+			want:    ErrNoAddonFolder,
+		},
+		{
+			name:    "empty",
+			entries: nil,
+			want:    ErrNoAddonFolder,
+		},
+		{
+			name:    "too large",
+			entries: []zipEntry{{name: "ElvUI/a.lua", content: "0123456789"}, {name: "ElvUI/b.lua", content: "0123456789"}},
+			opts:    &ExtractOptions{MaxSize: 15},
+			want:    ErrTooLarge,
+		},
+		{
+			name:    "too many files",
+			entries: []zipEntry{{name: "ElvUI/a.lua"}, {name: "ElvUI/b.lua"}, {name: "ElvUI/c.lua"}},
+			opts:    &ExtractOptions{MaxFiles: 2},
+			want:    ErrTooManyFiles,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := tempDir(t)
+			addonsDir := filepath.Join(dir, "AddOns")
+
+			existing := filepath.Join(addonsDir, "ElvUI", "ElvUI.toc")
+			os.MkdirAll(filepath.Dir(existing), 0755)
+			if err := ioutil.WriteFile(existing, []byte("old"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			zipPath := writeZip(t, dir, tt.entries...)
+
+			_, err := InstallZip(zipPath, addonsDir, tt.opts)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("InstallZip() returned %v, want %v", err, tt.want)
+			}
+
+			if got := listFiles(t, dir); len(got) != 2 || got[0] != "AddOns/ElvUI/ElvUI.toc" {
+				t.Errorf("InstallZip() left %v, want the existing addon only", got)
+			}
+		})
+	}
+}
+
+func TestInstallZip_RecoversInterruptedInstall(t *testing.T) {
+	dir := tempDir(t)
+	addonsDir := filepath.Join(dir, "AddOns")
+
+	// an installation that was interrupted after moving the old folder aside
+	old := filepath.Join(addonsDir, replacedPrefix+"123", "Details", "Details.toc")
+	os.MkdirAll(filepath.Dir(old), 0755)
+	if err := ioutil.WriteFile(old, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	staged := filepath.Join(addonsDir, stagingPrefix+"123", "Details", "Details.toc")
+	os.MkdirAll(filepath.Dir(staged), 0755)
+	if err := ioutil.WriteFile(staged, []byte("half"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	zipPath := writeZip(t, dir, zipEntry{name: "ElvUI/ElvUI.toc", content: "new"})
+
+	if _, err := InstallZip(zipPath, addonsDir, nil); err != nil {
+		t.Fatalf("InstallZip() returned error: %v", err)
+	}
+
+	want := []string{"Details/Details.toc", "ElvUI/ElvUI.toc"}
+	if got := listFiles(t, addonsDir); !cmp.Equal(got, want) {
+		t.Errorf("InstallZip() left %v, want %v", got, want)
+	}
+}
+
+func TestInstallZip_RollsBackInterruptedSwaps(t *testing.T) {
+	dir := tempDir(t)
+	addonsDir := filepath.Join(dir, "AddOns")
+
+	files := map[string]string{
+		// swapped before the crash
+		"ElvUI/ElvUI.toc":                      "new",
+		"ElvUI_Libraries/ElvUI_Libraries.toc":  "new",
+		replacedPrefix + "123/ElvUI/ElvUI.toc": "old",
+		// not swapped yet
+		"ElvUI_Options/ElvUI_Options.toc":                     "old",
+		stagingPrefix + "123/ElvUI_Options/ElvUI_Options.toc": "new",
+		"Details/Details.toc":                                 "other",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(addonsDir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	swaps := []swap{{"ElvUI", true}, {"ElvUI_Libraries", false}, {"ElvUI_Options", true}}
+	if err := writeJournal(filepath.Join(addonsDir, replacedPrefix+"123"), swaps); err != nil {
+		t.Fatal(err)
+	}
+
+	removeStaleStaging(addonsDir)
+
+	want := []string{"Details/Details.toc", "ElvUI/ElvUI.toc", "ElvUI_Options/ElvUI_Options.toc"}
+	if got := listFiles(t, addonsDir); !cmp.Equal(got, want) {
+		t.Fatalf("removeStaleStaging() left %v, want %v", got, want)
+	}
+
+	for _, name := range []string{"ElvUI/ElvUI.toc", "ElvUI_Options/ElvUI_Options.toc"} {
+		if data, _ := ioutil.ReadFile(filepath.Join(addonsDir, name)); string(data) != "old" {
+			t.Errorf("removeStaleStaging() left %s with %q, want the old version", name, data)
+		}
+	}
+}
+
+func TestInstallZip_KeepsCompletedSwaps(t *testing.T) {
+	dir := tempDir(t)
+	addonsDir := filepath.Join(dir, "AddOns")
+
+	// an installation that crashed while deleting the replaced folders
+	for name, content := range map[string]string{
+		"ElvUI/ElvUI.toc":                      "new",
+		obsoletePrefix + "123/ElvUI/ElvUI.toc": "old",
+	} {
+		path := filepath.Join(addonsDir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removeStaleStaging(addonsDir)
+
+	if got, want := listFiles(t, addonsDir), []string{"ElvUI/ElvUI.toc"}; !cmp.Equal(got, want) {
+		t.Fatalf("removeStaleStaging() left %v, want %v", got, want)
+	}
+
+	if data, _ := ioutil.ReadFile(filepath.Join(addonsDir, "ElvUI", "ElvUI.toc")); string(data) != "new" {
+		t.Errorf("removeStaleStaging() rolled back a completed installation")
+	}
+}
+
+func TestInstallZip_Concurrent(t *testing.T) {
+	dir := tempDir(t)
+	addonsDir := filepath.Join(dir, "AddOns")
+
+	zips := []string{
+		writeZip(t, dir, zipEntry{name: "ElvUI/ElvUI.toc", content: "elvui"}, zipEntry{name: "ElvUI_Options/ElvUI_Options.toc", content: "options"}),
+		writeZip(t, dir, zipEntry{name: "Details/Details.toc", content: "details"}),
+	}
+
+	var wg sync.WaitGroup
+	for _, zipPath := range zips {
+		wg.Add(1)
+		go func(zipPath string) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				if _, err := InstallZip(zipPath, addonsDir, nil); err != nil {
+					t.Errorf("InstallZip() returned error: %v", err)
+					return
+				}
+			}
+		}(zipPath)
+	}
+	wg.Wait()
+
+	want := []string{"Details/Details.toc", "ElvUI/ElvUI.toc", "ElvUI_Options/ElvUI_Options.toc"}
+	if got := listFiles(t, addonsDir); !cmp.Equal(got, want) {
+		t.Errorf("InstallZip() left %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,54 @@
+package tukui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Flavor identifies a World of Warcraft game client.
+type Flavor string
+
+const (
+	// FlavorRetail is the game client of the current expansion
+	FlavorRetail Flavor = "retail"
+	// FlavorClassic is the Classic Era game client with patch 1.x
+	FlavorClassic Flavor = "classic"
+	// FlavorBurningCrusade is the Burning Crusade Classic game client with patch 2.5.x
+	FlavorBurningCrusade Flavor = "bcc"
+	// FlavorWrath is the Wrath of the Lich King Classic game client with patch 3.4.x
+	FlavorWrath Flavor = "wrath"
+	// FlavorCataclysm is the Cataclysm Classic game client with patch 4.4.x
+	FlavorCataclysm Flavor = "cata"
+)
+
+// Flavors lists all known flavors.
+var Flavors = []Flavor{FlavorRetail, FlavorClassic, FlavorBurningCrusade, FlavorWrath, FlavorCataclysm}
+
+// ParseFlavor returns the Flavor for the given name. Besides the flavor values it
+// accepts some common aliases like "mainline" or "vanilla".
+func ParseFlavor(name string) (Flavor, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "retail", "mainline":
+		return FlavorRetail, nil
+	case "classic", "vanilla", "classic_era", "era":
+		return FlavorClassic, nil
+	case "bcc", "tbc", "burningcrusade":
+		return FlavorBurningCrusade, nil
+	case "wrath", "wotlk", "wotlkc":
+		return FlavorWrath, nil
+	case "cata", "cataclysm":
+		return FlavorCataclysm, nil
+	}
+
+	return "", fmt.Errorf("unknown flavor %q", name)
+}
+
+// IsClassic reports whether the flavor is one of the Classic game clients.
+func (f Flavor) IsClassic() bool {
+	switch f {
+	case FlavorClassic, FlavorBurningCrusade, FlavorWrath, FlavorCataclysm:
+		return true
+	}
+
+	return false
+}
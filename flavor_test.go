@@ -0,0 +1,20 @@
+package tukui
+
+import "testing"
+
+func TestParseFlavor(t *testing.T) {
+	for _, flavor := range Flavors {
+		got, err := ParseFlavor(string(flavor))
+		if err != nil || got != flavor {
+			t.Errorf("ParseFlavor(%q) returned %q, %v", flavor, got, err)
+		}
+	}
+
+	if got, _ := ParseFlavor("Vanilla"); got != FlavorClassic {
+		t.Errorf("ParseFlavor(%q) returned %q, want %q", "Vanilla", got, FlavorClassic)
+	}
+
+	if _, err := ParseFlavor("mists"); err == nil {
+		t.Errorf("ParseFlavor(%q) returned no error", "mists")
+	}
+}
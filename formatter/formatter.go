@@ -0,0 +1,471 @@
+// Package formatter renders catalog data, like addons and update reports, as tables,
+// JSON, newline delimited JSON, CSV or YAML.
+package formatter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// ErrUnknownField is returned if a selected field is not a column of the List.
+var ErrUnknownField = errors.New("unknown field")
+
+// Format is an output format.
+type Format string
+
+const (
+	// Table renders an aligned table for humans
+	Table Format = "table"
+	// JSON renders an indented JSON array of objects
+	JSON Format = "json"
+	// NDJSON renders one JSON object per line, which can be processed as a stream
+	NDJSON Format = "ndjson"
+	// CSV renders RFC 4180 comma separated values with a header line
+	CSV Format = "csv"
+	// YAML renders a YAML sequence of mappings
+	YAML Format = "yaml"
+)
+
+// Formats are all supported formats.
+var Formats = []Format{Table, JSON, NDJSON, CSV, YAML}
+
+// ParseFormat returns the Format with the given name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case Table, JSON, NDJSON, CSV, YAML:
+		return f, nil
+	case "yml":
+		return YAML, nil
+	case "jsonl":
+		return NDJSON, nil
+	}
+
+	return "", fmt.Errorf("unknown format %q", name)
+}
+
+// DefaultWidth is the width truncated table columns are limited to.
+const DefaultWidth = 40
+
+// A Column of a List.
+type Column struct {
+	// Name of the column, used as key, header and to select fields
+	Name string
+	// Truncate limits the cells of the column to Options.Width in tables
+	Truncate bool
+}
+
+// A List holds records with the same columns. Values are strings, integers, booleans
+// or nil for missing values.
+type List struct {
+	// Columns in their stable order
+	Columns []Column
+	// Default are the names of the columns shown in tables if no fields are selected.
+	// All columns are shown if it is empty.
+	Default []string
+	// Rows hold one value per column
+	Rows [][]interface{}
+}
+
+// Options changes how a List is rendered.
+type Options struct {
+	// Format of the output, the zero value is Table
+	Format Format
+	// Fields selects and orders the columns. Nil selects all columns, or the
+	// default columns of the List for tables that are not vertical.
+	Fields []string
+	// Width of truncated table columns, 0 uses DefaultWidth
+	Width int
+	// Vertical renders tables as one "field: value" line per column, which suits
+	// single records
+	Vertical bool
+}
+
+// ParseFields splits a comma separated list of field names.
+func ParseFields(s string) []string {
+	var fields []string
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// Write renders the list to w.
+func Write(w io.Writer, list *List, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = Table
+	}
+
+	fields := opts.Fields
+	if fields == nil && format == Table && !opts.Vertical {
+		fields = list.Default
+	}
+
+	selected, err := list.selectColumns(fields)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case Table:
+		if opts.Vertical {
+			return writeVertical(w, list, selected)
+		}
+		return writeTable(w, list, selected, opts.Width)
+	case JSON:
+		return writeJSON(w, list, selected)
+	case NDJSON:
+		return writeNDJSON(w, list, selected)
+	case CSV:
+		return writeCSV(w, list, selected)
+	case YAML:
+		return writeYAML(w, list, selected)
+	}
+
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// selectColumns returns the indexes of the selected columns.
+func (l *List) selectColumns(fields []string) ([]int, error) {
+	if len(fields) == 0 {
+		all := make([]int, len(l.Columns))
+		for i := range all {
+			all[i] = i
+		}
+		return all, nil
+	}
+
+	selected := make([]int, 0, len(fields))
+	for _, field := range fields {
+		i := l.column(field)
+		if i < 0 {
+			return nil, fmt.Errorf("%w %q, valid fields are %s", ErrUnknownField, field, strings.Join(l.names(), ", "))
+		}
+		selected = append(selected, i)
+	}
+
+	return selected, nil
+}
+
+func (l *List) column(name string) int {
+	for i, c := range l.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (l *List) names() []string {
+	names := make([]string, len(l.Columns))
+	for i, c := range l.Columns {
+		names[i] = c.Name
+	}
+
+	return names
+}
+
+// missing reports whether v is nil or a nil string pointer.
+func missing(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case *string:
+		return v == nil
+	case []string:
+		return v == nil
+	}
+
+	return false
+}
+
+// text returns the value as plain text, missing values are empty. Lists are joined
+// with commas.
+func text(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case []string:
+		return strings.Join(v, ", ")
+	}
+
+	return fmt.Sprint(v)
+}
+
+func writeTable(w io.Writer, list *List, selected []int, width int) error {
+	if width <= 0 {
+		width = DefaultWidth
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	headers := make([]string, len(selected))
+	for i, c := range selected {
+		headers[i] = strings.ToUpper(list.Columns[c].Name)
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+
+	cells := make([]string, len(selected))
+	for _, row := range list.Rows {
+		for i, c := range selected {
+			cell := cleanCell(text(row[c]))
+			if list.Columns[c].Truncate {
+				cell = truncate(cell, width)
+			}
+			cells[i] = cell
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+func writeVertical(w io.Writer, list *List, selected []int) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	for n, row := range list.Rows {
+		if n > 0 {
+			fmt.Fprintln(tw)
+		}
+
+		for _, c := range selected {
+			if !missing(row[c]) {
+				fmt.Fprintf(tw, "%s:\t%s\n", list.Columns[c].Name, cleanCell(text(row[c])))
+			}
+		}
+	}
+
+	return tw.Flush()
+}
+
+// cleanCell replaces characters that would break the alignment of a table.
+func cleanCell(s string) string {
+	return strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+}
+
+// truncate shortens s to at most width runes, marking the cut with an ellipsis.
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+
+	runes := []rune(s)
+	if width <= 1 {
+		return string(runes[:width])
+	}
+
+	return strings.TrimSpace(string(runes[:width-1])) + "…"
+}
+
+// object encodes the selected values of a row as a JSON object with the keys in
+// column order.
+func object(list *List, row []interface{}, selected []int) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for i, c := range selected {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := marshal(list.Columns[c].Name)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := marshal(row[c])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func writeJSON(w io.Writer, list *List, selected []int) error {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+
+	for n, row := range list.Rows {
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+
+		obj, err := object(list, row, selected)
+		if err != nil {
+			return err
+		}
+		buf.Write(obj)
+	}
+
+	buf.WriteByte(']')
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+
+	_, err := out.WriteTo(w)
+	return err
+}
+
+func writeNDJSON(w io.Writer, list *List, selected []int) error {
+	for _, row := range list.Rows {
+		obj, err := object(list, row, selected)
+		if err != nil {
+			return err
+		}
+
+		if _, err := w.Write(append(obj, '\n')); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeCSV(w io.Writer, list *List, selected []int) error {
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+
+	record := make([]string, len(selected))
+	for i, c := range selected {
+		record[i] = list.Columns[c].Name
+	}
+
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+
+	for _, row := range list.Rows {
+		for i, c := range selected {
+			record[i] = text(row[c])
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func writeYAML(w io.Writer, list *List, selected []int) error {
+	if len(list.Rows) == 0 {
+		_, err := io.WriteString(w, "[]\n")
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, row := range list.Rows {
+		if len(selected) == 0 {
+			buf.WriteString("- {}\n")
+			continue
+		}
+
+		for i, c := range selected {
+			prefix := "  "
+			if i == 0 {
+				prefix = "- "
+			}
+
+			buf.WriteString(prefix)
+			buf.WriteString(yamlKey(list.Columns[c].Name))
+			buf.WriteString(": ")
+			buf.WriteString(yamlValue(row[c]))
+			buf.WriteByte('\n')
+		}
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func yamlKey(name string) string {
+	for _, r := range name {
+		if !(r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return yamlString(name)
+		}
+	}
+
+	return name
+}
+
+// yamlValue renders a scalar. Strings are always double quoted, which makes them
+// JSON strings and avoids YAML's implicit typing of values like "yes" or "1.10".
+func yamlValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case *string:
+		if v == nil {
+			return "null"
+		}
+		return yamlString(*v)
+	case []string:
+		// a JSON array is a YAML flow sequence
+		if v == nil {
+			return "null"
+		}
+		b, _ := marshal(v)
+		return string(b)
+	}
+
+	return yamlString(text(v))
+}
+
+func yamlString(s string) string {
+	b, _ := marshal(s)
+	return string(b)
+}
+
+// marshal encodes v as JSON without escaping HTML characters like & in names.
+func marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,142 @@
+package formatter
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func String(s string) *string {
+	return &s
+}
+
+func testList() *List {
+	return &List{
+		Columns: []Column{{Name: "id"}, {Name: "name"}, {Name: "small_desc", Truncate: true}, {Name: "downloads"}},
+		Default: []string{"id", "name", "small_desc"},
+		Rows: [][]interface{}{
+			{String("3"), String("AddOnSkins"), String("Skins for \"all\" AddOns, and more"), 1200},
+			{"10", "Shadow & Light", nil, (*string)(nil)},
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *Options
+		want string
+	}{
+		{
+			name: "table",
+			opts: &Options{Width: 20},
+			want: "ID  NAME            SMALL_DESC\n" +
+				"3   AddOnSkins      Skins for \"all\" Add…\n" +
+				"10  Shadow & Light  \n",
+		},
+		{
+			name: "vertical table",
+			opts: &Options{Vertical: true, Fields: []string{"name", "downloads"}},
+			want: "name:       AddOnSkins\n" +
+				"downloads:  1200\n" +
+				"\n" +
+				"name:  Shadow & Light\n",
+		},
+		{
+			name: "json",
+			opts: &Options{Format: JSON, Fields: []string{"name", "id", "downloads"}},
+			want: "[\n" +
+				"  {\n    \"name\": \"AddOnSkins\",\n    \"id\": \"3\",\n    \"downloads\": 1200\n  },\n" +
+				"  {\n    \"name\": \"Shadow & Light\",\n    \"id\": \"10\",\n    \"downloads\": null\n  }\n" +
+				"]\n",
+		},
+		{
+			name: "ndjson",
+			opts: &Options{Format: NDJSON},
+			want: `{"id":"3","name":"AddOnSkins","small_desc":"Skins for \"all\" AddOns, and more","downloads":1200}` + "\n" +
+				`{"id":"10","name":"Shadow & Light","small_desc":null,"downloads":null}` + "\n",
+		},
+		{
+			name: "csv",
+			opts: &Options{Format: CSV},
+			want: "id,name,small_desc,downloads\r\n" +
+				"3,AddOnSkins,\"Skins for \"\"all\"\" AddOns, and more\",1200\r\n" +
+				"10,Shadow & Light,,\r\n",
+		},
+		{
+			name: "yaml",
+			opts: &Options{Format: YAML, Fields: []string{"ID", "small_desc", "downloads"}},
+			want: "- id: \"3\"\n" +
+				"  small_desc: \"Skins for \\\"all\\\" AddOns, and more\"\n" +
+				"  downloads: 1200\n" +
+				"- id: \"10\"\n" +
+				"  small_desc: null\n" +
+				"  downloads: null\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, testList(), tt.opts); err != nil {
+				t.Fatalf("Write() returned error: %v", err)
+			}
+
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Write() returned\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite_Empty(t *testing.T) {
+	list := &List{Columns: []Column{{Name: "id"}}}
+
+	for format, want := range map[Format]string{
+		Table:  "ID\n",
+		JSON:   "[]\n",
+		NDJSON: "",
+		CSV:    "id\r\n",
+		YAML:   "[]\n",
+	} {
+		var buf bytes.Buffer
+		if err := Write(&buf, list, &Options{Format: format}); err != nil {
+			t.Fatalf("Write(%s) returned error: %v", format, err)
+		}
+
+		if buf.String() != want {
+			t.Errorf("Write(%s) returned %q, want %q", format, buf.String(), want)
+		}
+	}
+}
+
+func TestWrite_UnknownField(t *testing.T) {
+	for _, format := range Formats {
+		err := Write(&bytes.Buffer{}, testList(), &Options{Format: format, Fields: []string{"id", "title"}})
+		if !errors.Is(err, ErrUnknownField) || !strings.Contains(err.Error(), "small_desc") {
+			t.Errorf("Write(%s) returned %v, want %v listing the fields", format, err, ErrUnknownField)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, want := range map[string]Format{"table": Table, "JSON": JSON, "jsonl": NDJSON, "csv": CSV, "yml": YAML} {
+		if got, err := ParseFormat(name); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) returned %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("ParseFormat(%q) returned no error", "xml")
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	got := ParseFields(" id, name,,version ")
+	if want := []string{"id", "name", "version"}; !cmp.Equal(got, want) {
+		t.Errorf("ParseFields() returned %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,164 @@
+package formatter
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/unly/go-tukui"
+)
+
+// addonColumns are the fields of tukui.Addon, named after their JSON keys.
+var addonColumns = []Column{
+	{Name: "id"},
+	{Name: "name"},
+	{Name: "small_desc", Truncate: true},
+	{Name: "author"},
+	{Name: "version"},
+	{Name: "screenshot_url"},
+	{Name: "url"},
+	{Name: "category"},
+	{Name: "downloads"},
+	{Name: "lastupdate"},
+	{Name: "patch"},
+	{Name: "web_url"},
+	{Name: "last_download"},
+	{Name: "donate_url"},
+	{Name: "slug"},
+	{Name: "changelog_url"},
+	{Name: "ticket_url"},
+	{Name: "git_url"},
+	{Name: "logo_url"},
+	{Name: "directories"},
+	{Name: "patches"},
+}
+
+func addonRow(a tukui.Addon) []interface{} {
+	return []interface{}{
+		a.Id, a.Name, a.SmallDesc, a.Author, a.Version, a.ScreenshotUrl, a.URL,
+		a.Category, a.Downloads, a.LastUpdate, a.Patch, a.WebUrl, a.LastDownload, a.DonateUrl,
+		a.Slug, a.ChangelogUrl, a.TicketUrl, a.GitUrl, a.LogoUrl, a.Directories, a.Patches,
+	}
+}
+
+// Addons returns a List of the addons with the fields of tukui.Addon as columns.
+func Addons(addons []tukui.Addon) *List {
+	list := &List{
+		Columns: addonColumns,
+		Default: []string{"id", "name", "version", "lastupdate", "small_desc"},
+	}
+
+	for _, a := range addons {
+		list.Rows = append(list.Rows, addonRow(a))
+	}
+
+	return list
+}
+
+// Updates returns a List of an update report as created by tukui.Client.CheckUpdates.
+func Updates(updates []tukui.Update) *List {
+	list := &List{
+		Columns: []Column{
+			{Name: "name"},
+			{Name: "id"},
+			{Name: "folder"},
+			{Name: "installed"},
+			{Name: "available"},
+			{Name: "lastupdate"},
+			{Name: "status"},
+			{Name: "match"},
+		},
+		Default: []string{"name", "installed", "available", "lastupdate", "status"},
+	}
+
+	for _, u := range updates {
+		row := []interface{}{u.Installed.Name(), nil, u.Installed.Name(), u.LocalVersion, nil, nil, u.Status.String(), u.Installed.Confidence.String()}
+		if u.Addon != nil {
+			row[0], row[1] = u.Addon.Name, u.Addon.Id
+			row[4], row[5] = u.RemoteVersion, u.LastUpdate
+		}
+
+		list.Rows = append(list.Rows, row)
+	}
+
+	return list
+}
+
+// Change types of a catalog diff.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Updated = "updated"
+)
+
+// Diff returns a List of the addons added, removed or updated between two versions
+// of a catalog, sorted by ID. Numeric IDs are sorted by their value and before
+// other IDs.
+func Diff(old, new []tukui.Addon) *List {
+	list := &List{
+		Columns: []Column{
+			{Name: "change"},
+			{Name: "id"},
+			{Name: "name"},
+			{Name: "old_version"},
+			{Name: "new_version"},
+			{Name: "lastupdate"},
+		},
+	}
+
+	before := make(map[string]tukui.Addon)
+	for _, a := range old {
+		if a.Id != nil {
+			before[*a.Id] = a
+		}
+	}
+
+	after := make(map[string]bool)
+	for _, a := range new {
+		if a.Id == nil {
+			continue
+		}
+		after[*a.Id] = true
+
+		prev, ok := before[*a.Id]
+		switch {
+		case !ok:
+			list.Rows = append(list.Rows, []interface{}{Added, a.Id, a.Name, nil, a.Version, a.LastUpdate})
+		case value(prev.Version) != value(a.Version):
+			list.Rows = append(list.Rows, []interface{}{Updated, a.Id, a.Name, prev.Version, a.Version, a.LastUpdate})
+		}
+	}
+
+	for _, a := range old {
+		if a.Id != nil && !after[*a.Id] {
+			list.Rows = append(list.Rows, []interface{}{Removed, a.Id, a.Name, a.Version, nil, a.LastUpdate})
+		}
+	}
+
+	sort.SliceStable(list.Rows, func(i, j int) bool {
+		return lessID(text(list.Rows[i][1]), text(list.Rows[j][1]))
+	})
+
+	return list
+}
+
+func lessID(a, b string) bool {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+
+	switch {
+	case errA == nil && errB == nil:
+		return x < y
+	case errA == nil || errB == nil:
+		return errA == nil
+	default:
+		return a < b
+	}
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
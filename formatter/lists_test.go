@@ -0,0 +1,137 @@
+package formatter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/unly/go-tukui"
+)
+
+func TestAddons(t *testing.T) {
+	addons := []tukui.Addon{
+		{Id: String("3"), Name: String("AddOnSkins"), Version: String("3.53"), Downloads: String("1200")},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, Addons(addons), &Options{Format: CSV, Fields: []string{"id", "name", "version", "downloads", "donate_url"}}); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+
+	want := "id,name,version,downloads,donate_url\r\n3,AddOnSkins,3.53,1200,\r\n"
+	if buf.String() != want {
+		t.Errorf("Write(Addons()) returned %q, want %q", buf.String(), want)
+	}
+
+	list := Addons(nil)
+	if got := list.names(); len(got) != 21 || got[0] != "id" || got[13] != "donate_url" || got[20] != "patches" {
+		t.Errorf("Addons() has columns %v", got)
+	}
+}
+
+func TestAddons_Lists(t *testing.T) {
+	addons := []tukui.Addon{
+		{
+			Slug:        String("elvui"),
+			GitUrl:      String("https://github.com/tukui-org/ElvUI"),
+			Directories: []string{"ElvUI", "ElvUI_Options"},
+			Patches:     []string{"10.1.5", "3.4.2"},
+		},
+		{Slug: String("addonskins")},
+	}
+	fields := []string{"slug", "git_url", "directories", "patches"}
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{CSV, "slug,git_url,directories,patches\r\nelvui,https://github.com/tukui-org/ElvUI,\"ElvUI, ElvUI_Options\",\"10.1.5, 3.4.2\"\r\naddonskins,,,\r\n"},
+		{NDJSON, `{"slug":"elvui","git_url":"https://github.com/tukui-org/ElvUI","directories":["ElvUI","ElvUI_Options"],"patches":["10.1.5","3.4.2"]}` + "\n" +
+			`{"slug":"addonskins","git_url":null,"directories":null,"patches":null}` + "\n"},
+		{YAML, "- slug: \"elvui\"\n  git_url: \"https://github.com/tukui-org/ElvUI\"\n  directories: [\"ElvUI\",\"ElvUI_Options\"]\n  patches: [\"10.1.5\",\"3.4.2\"]\n" +
+			"- slug: \"addonskins\"\n  git_url: null\n  directories: null\n  patches: null\n"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := Write(&buf, Addons(addons), &Options{Format: tt.format, Fields: fields}); err != nil {
+			t.Fatalf("Write(%v) returned error: %v", tt.format, err)
+		}
+
+		if buf.String() != tt.want {
+			t.Errorf("Write(%v) returned %q, want %q", tt.format, buf.String(), tt.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, Addons(addons[:1]), &Options{Format: Table, Fields: fields, Vertical: true}); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+
+	if want := "directories:  ElvUI, ElvUI_Options\n"; !bytes.Contains(buf.Bytes(), []byte(want)) {
+		t.Errorf("Write() returned %q, want it to contain %q", buf.String(), want)
+	}
+}
+
+func TestUpdates(t *testing.T) {
+	updates := []tukui.Update{
+		{
+			Installed:     tukui.InstalledAddon{Folders: []tukui.InstalledFolder{{Name: "ElvUI"}}, Confidence: tukui.MatchTitle},
+			Addon:         &tukui.Addon{Id: String("-2"), Name: String("ElvUI")},
+			LocalVersion:  "13.60",
+			RemoteVersion: "13.64",
+			LastUpdate:    "2024-01-02",
+			Status:        tukui.StatusOutdated,
+		},
+		{
+			Installed:    tukui.InstalledAddon{Folders: []tukui.InstalledFolder{{Name: "Details"}}},
+			LocalVersion: "1.0",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, Updates(updates), &Options{Format: NDJSON}); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+
+	want := `{"name":"ElvUI","id":"-2","folder":"ElvUI","installed":"13.60","available":"13.64","lastupdate":"2024-01-02","status":"outdated","match":"title"}` + "\n" +
+		`{"name":"Details","id":null,"folder":"Details","installed":"1.0","available":null,"lastupdate":null,"status":"unknown","match":"none"}` + "\n"
+	if buf.String() != want {
+		t.Errorf("Write(Updates()) returned\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := []tukui.Addon{
+		{Id: String("3"), Name: String("AddOnSkins"), Version: String("3.53")},
+		{Id: String("6"), Name: String("LocationPlus"), Version: String("2.48")},
+		{Id: String("7"), Name: String("Removed"), Version: String("1.0")},
+	}
+	new := []tukui.Addon{
+		{Id: String("3"), Name: String("AddOnSkins"), Version: String("3.54")},
+		{Id: String("6"), Name: String("LocationPlus"), Version: String("2.48")},
+		{Id: String("10"), Name: String("Shadow & Light"), Version: String("5.01")},
+		{Id: String("9"), Name: String("WindTools"), Version: String("1.0")},
+		{Id: String("-2"), Name: String("ElvUI"), Version: String("12.0")},
+	}
+
+	var got [][]string
+	for _, row := range Diff(old, new).Rows {
+		var cells []string
+		for _, v := range row[:5] {
+			cells = append(cells, text(v))
+		}
+		got = append(got, cells)
+	}
+
+	want := [][]string{
+		{Added, "-2", "ElvUI", "", "12.0"},
+		{Updated, "3", "AddOnSkins", "3.53", "3.54"},
+		{Removed, "7", "Removed", "1.0", ""},
+		{Added, "9", "WindTools", "", "1.0"},
+		{Added, "10", "Shadow & Light", "", "5.01"},
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("Diff() returned %v, want %v", got, want)
+	}
+}
@@ -1,5 +1,7 @@
 module github.com/unly/go-tukui
 
-go 1.15
+go 1.18
 
 require github.com/google/go-cmp v0.5.2
+
+require golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
@@ -0,0 +1,249 @@
+package tukui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrLockMismatch is returned if a pinned addon version cannot be installed exactly.
+var ErrLockMismatch = errors.New("addon does not match the lockfile")
+
+// An Installer downloads addons and installs them into an AddOns directory. Every
+// installation is recorded in a Manifest next to the AddOns directory.
+//
+// An Installer is safe for concurrent use, but only one Installer should manage an
+// AddOns directory at a time.
+type Installer struct {
+	client    *Client
+	flavor    Flavor
+	addonsDir string
+	mu        sync.Mutex
+
+	// Store caches the downloaded .zip files. It is required to reinstall pinned
+	// versions of a Lockfile once the catalog has a newer version.
+	Store *Store
+	// Extract limits the content of the .zip files, nil uses the default limits
+	Extract *ExtractOptions
+	// Backup takes a Snapshot of an installed addon before it is replaced, nil
+	// disables snapshots
+	Backup *BackupOptions
+}
+
+// NewInstaller creates an Installer for the AddOns directory of a game client with the given flavor.
+func NewInstaller(client *Client, flavor Flavor, addonsDir string) *Installer {
+	return &Installer{
+		client:    client,
+		flavor:    flavor,
+		addonsDir: addonsDir,
+	}
+}
+
+// ManifestPath returns the path of the manifest file, which is stored next to the
+// AddOns directory and not inside it.
+func (i *Installer) ManifestPath() string {
+	return manifestPath(i.addonsDir)
+}
+
+func manifestPath(addonsDir string) string {
+	return filepath.Join(filepath.Dir(filepath.Clean(addonsDir)), ManifestFile)
+}
+
+// Manifest reads the current manifest.
+func (i *Installer) Manifest() (*Manifest, error) {
+	return ReadManifest(i.ManifestPath())
+}
+
+// Install downloads the given catalog Addon, installs it and records it in the manifest.
+func (i *Installer) Install(ctx context.Context, addon Addon) (ManifestEntry, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.install(ctx, addon, "")
+}
+
+// InstallLocked installs every addon of the lockfile for the flavor of the Installer at
+// exactly the pinned version. Each .zip file is verified against the pinned checksum
+// before anything is installed. If the catalog moved on to a newer version, the pinned
+// version has to be available from the Store, otherwise ErrLockMismatch is returned.
+func (i *Installer) InstallLocked(ctx context.Context, lock *Lockfile) ([]ManifestEntry, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var entries []ManifestEntry
+	for _, locked := range lock.Addons {
+		if locked.Flavor != i.flavor {
+			continue
+		}
+
+		addon := Addon{
+			Id:      &locked.ID,
+			Name:    &locked.Name,
+			Version: &locked.Version,
+			URL:     &locked.URL,
+		}
+
+		entry, err := i.install(ctx, addon, locked.SHA256)
+		if err != nil {
+			return entries, fmt.Errorf("addon %s: %w", locked.ID, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// install fetches and installs the addon. If sum is not empty, the .zip file must
+// have this checksum.
+func (i *Installer) install(ctx context.Context, addon Addon, sum string) (ManifestEntry, error) {
+	if _, _, ok := storeKey(addon); !ok {
+		return ManifestEntry{}, errors.New("addon has no id or version")
+	}
+
+	manifest, err := i.Manifest()
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+
+	tmp, err := ioutil.TempDir("", "tukui-install-")
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	defer os.RemoveAll(tmp)
+
+	zipPath := filepath.Join(tmp, "addon.zip")
+	result, err := i.fetch(ctx, addon, zipPath, sum)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+
+	return i.installFile(manifest, addon, zipPath, result)
+}
+
+// installFile installs the fetched .zip file of the addon and records it in the manifest.
+func (i *Installer) installFile(manifest *Manifest, addon Addon, zipPath string, result DownloadResult) (ManifestEntry, error) {
+	id, version, ok := storeKey(addon)
+	if !ok {
+		return ManifestEntry{}, errors.New("addon has no id or version")
+	}
+
+	old, update := manifest.Entry(id)
+	if update && i.Backup != nil {
+		if err := i.snapshot(old); err != nil {
+			return ManifestEntry{}, fmt.Errorf("backup of %s %s: %w", id, old.Version, err)
+		}
+	}
+
+	folders, err := InstallZip(zipPath, i.addonsDir, i.Extract)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+
+	if update {
+		if err := i.removeStaleFolders(manifest, old, folders); err != nil {
+			return ManifestEntry{}, err
+		}
+	}
+
+	entry := ManifestEntry{
+		ID:          id,
+		Name:        stringValue(addon.Name),
+		Flavor:      i.flavor,
+		Version:     version,
+		SHA256:      result.SHA256,
+		URL:         stringValue(addon.URL),
+		Folders:     folders,
+		InstalledAt: time.Now().UTC(),
+	}
+
+	manifest.set(entry)
+	if err := manifest.Write(i.ManifestPath()); err != nil {
+		return entry, err
+	}
+
+	// snapshots are only pruned once the update succeeded, a failed update keeps
+	// the older ones to roll back to
+	if update && i.Backup != nil {
+		return entry, i.pruneSnapshots(id)
+	}
+
+	return entry, nil
+}
+
+// removeStaleFolders deletes the folders of the old entry that are neither part of
+// the new version nor owned by another addon of the manifest.
+func (i *Installer) removeStaleFolders(manifest *Manifest, old ManifestEntry, folders []string) error {
+	keep := make(map[string]bool)
+	for _, folder := range folders {
+		keep[strings.ToLower(folder)] = true
+	}
+	for _, other := range manifest.Addons {
+		if other.ID != old.ID {
+			for _, folder := range other.Folders {
+				keep[strings.ToLower(folder)] = true
+			}
+		}
+	}
+
+	for _, folder := range old.Folders {
+		if !keep[strings.ToLower(folder)] && isFolderName(folder) {
+			if err := os.RemoveAll(filepath.Join(i.addonsDir, folder)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fetch writes the .zip file of the addon to path. Pinned versions are taken from
+// the Store if possible and only added to it after their checksum was verified.
+func (i *Installer) fetch(ctx context.Context, addon Addon, path, sum string) (DownloadResult, error) {
+	if sum == "" {
+		result, _, err := i.client.DownloadFile(ctx, addon, path, &DownloadOptions{Store: i.Store})
+		return result, err
+	}
+
+	id, version, _ := storeKey(addon)
+	if i.Store != nil {
+		if r, entry, err := i.Store.Open(id, version); err == nil {
+			defer r.Close()
+			if entry.SHA256 == sum {
+				return DownloadResult{Size: entry.Size, SHA256: entry.SHA256, Cached: true}, copyToFile(path, r)
+			}
+		}
+	}
+
+	result, _, err := i.client.DownloadFile(ctx, addon, path, nil)
+	if err != nil {
+		return result, err
+	}
+
+	if result.SHA256 != sum {
+		return result, fmt.Errorf("%w: checksum %s, want %s", ErrLockMismatch, result.SHA256, sum)
+	}
+
+	return result, addFileToStore(i.Store, addon, path)
+}
+
+func copyToFile(path string, r io.Reader) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,226 @@
+package tukui
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// newTestInstaller returns an Installer for an AddOns directory in a new temporary
+// directory and the addon served at /download by mux.
+func newTestInstaller(t *testing.T, client *Client, version string) (*Installer, Addon) {
+	t.Helper()
+	addonsDir := filepath.Join(tempDir(t), "Interface", "AddOns")
+	addon := Addon{
+		Id:      String("3"),
+		Name:    String("AddOnSkins"),
+		Version: String(version),
+		URL:     String(client.url + "download"),
+	}
+
+	return NewInstaller(client, FlavorRetail, addonsDir), addon
+}
+
+func addonZip(t *testing.T, version string) []byte {
+	return buildZip(t,
+		zipEntry{name: "AddOnSkins/AddOnSkins.toc", content: "## Version: " + version},
+		zipEntry{name: "AddOnSkins_Plugins/AddOnSkins_Plugins.toc", content: "## Version: " + version},
+	)
+}
+
+func TestInstaller_Install(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	payload := addonZip(t, "3.53")
+	mux.HandleFunc("/download", serveZip(`"v1"`, payload))
+
+	installer, addon := newTestInstaller(t, client, "3.53")
+
+	entry, err := installer.Install(context.Background(), addon)
+	if err != nil {
+		t.Fatalf("Installer.Install() returned error: %v", err)
+	}
+
+	want := ManifestEntry{
+		ID:      "3",
+		Name:    "AddOnSkins",
+		Flavor:  FlavorRetail,
+		Version: "3.53",
+		SHA256:  sha256Hex(payload),
+		URL:     client.url + "download",
+		Folders: []string{"AddOnSkins", "AddOnSkins_Plugins"},
+	}
+	ignoreTime := cmpopts.IgnoreFields(ManifestEntry{}, "InstalledAt")
+	if !cmp.Equal(entry, want, ignoreTime) {
+		t.Errorf("Installer.Install() returned %+v, want %+v", entry, want)
+	}
+
+	if entry.InstalledAt.IsZero() {
+		t.Errorf("Installer.Install() returned no installation time")
+	}
+
+	wantFiles := []string{"AddOnSkins/AddOnSkins.toc", "AddOnSkins_Plugins/AddOnSkins_Plugins.toc"}
+	if got := listFiles(t, installer.addonsDir); !cmp.Equal(got, wantFiles) {
+		t.Errorf("Installer.Install() created %v, want %v", got, wantFiles)
+	}
+
+	wantPath := filepath.Join(filepath.Dir(installer.addonsDir), ManifestFile)
+	if installer.ManifestPath() != wantPath {
+		t.Errorf("Installer.ManifestPath() returned %q, want %q", installer.ManifestPath(), wantPath)
+	}
+
+	manifest, err := installer.Manifest()
+	if err != nil {
+		t.Fatalf("Installer.Manifest() returned error: %v", err)
+	}
+
+	if !cmp.Equal(manifest.Addons, []ManifestEntry{entry}) {
+		t.Errorf("Installer.Manifest() returned %+v, want %+v", manifest.Addons, []ManifestEntry{entry})
+	}
+}
+
+func TestInstaller_Install_Update(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	payload := addonZip(t, "3.53")
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		serveZip(`"v"`, payload)(w, r)
+	})
+
+	installer, addon := newTestInstaller(t, client, "3.53")
+	if _, err := installer.Install(context.Background(), addon); err != nil {
+		t.Fatal(err)
+	}
+
+	payload = buildZip(t, zipEntry{name: "AddOnSkins/AddOnSkins.toc", content: "## Version: 3.54"})
+	addon.Version = String("3.54")
+	if _, err := installer.Install(context.Background(), addon); err != nil {
+		t.Fatalf("Installer.Install() returned error: %v", err)
+	}
+
+	manifest, err := installer.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(manifest.Addons) != 1 || manifest.Addons[0].Version != "3.54" {
+		t.Errorf("Installer.Install() recorded %+v, want version 3.54 only", manifest.Addons)
+	}
+
+	got, err := ioutil.ReadFile(filepath.Join(installer.addonsDir, "AddOnSkins", "AddOnSkins.toc"))
+	if err != nil || string(got) != "## Version: 3.54" {
+		t.Errorf("Installer.Install() left %q, %v", got, err)
+	}
+
+	// the folder dropped by the new version is not tracked anymore and must be deleted
+	wantFiles := []string{"AddOnSkins/AddOnSkins.toc"}
+	if got := listFiles(t, installer.addonsDir); !cmp.Equal(got, wantFiles) {
+		t.Errorf("Installer.Install() left %v, want %v", got, wantFiles)
+	}
+}
+
+func TestLockfile_ReadWrite(t *testing.T) {
+	lock := &Lockfile{Addons: []LockedAddon{
+		{ID: "3", Name: "AddOnSkins", Flavor: FlavorRetail, Version: "3.53", SHA256: "abc", URL: "https://example.com/a.zip"},
+		{ID: "2", Flavor: FlavorClassic, Version: "1.31", SHA256: "def"},
+	}}
+
+	path := filepath.Join(tempDir(t), "tukui.lock")
+	if err := lock.Write(path); err != nil {
+		t.Fatalf("Lockfile.Write() returned error: %v", err)
+	}
+
+	got, err := ReadLockfile(path)
+	if err != nil {
+		t.Fatalf("ReadLockfile() returned error: %v", err)
+	}
+
+	if !cmp.Equal(got, lock) {
+		t.Errorf("ReadLockfile() returned %+v, want %+v", got, lock)
+	}
+}
+
+func TestInstaller_InstallLocked(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	payload := addonZip(t, "3.53")
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		serveZip(`"v"`, payload)(w, r)
+	})
+
+	store := newTestStore(t)
+	installer, addon := newTestInstaller(t, client, "3.53")
+	installer.Store = store
+	if _, err := installer.Install(context.Background(), addon); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := installer.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := manifest.Lock()
+	lock.Addons = append(lock.Addons, LockedAddon{ID: "2", Flavor: FlavorClassic, Version: "1.31"})
+
+	// the catalog moved on, the pinned version is only available from the store
+	payload = addonZip(t, "3.54")
+
+	other, _ := newTestInstaller(t, client, "3.54")
+	other.Store = store
+
+	entries, err := other.InstallLocked(context.Background(), lock)
+	if err != nil {
+		t.Fatalf("Installer.InstallLocked() returned error: %v", err)
+	}
+
+	if len(entries) != 1 || entries[0].Version != "3.53" || entries[0].SHA256 != lock.Addons[0].SHA256 {
+		t.Errorf("Installer.InstallLocked() returned %+v, want the pinned version", entries)
+	}
+
+	got, err := ioutil.ReadFile(filepath.Join(other.addonsDir, "AddOnSkins", "AddOnSkins.toc"))
+	if err != nil || string(got) != "## Version: 3.53" {
+		t.Errorf("Installer.InstallLocked() installed %q, %v", got, err)
+	}
+}
+
+func TestInstaller_InstallLocked_Mismatch(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/download", serveZip(`"v"`, addonZip(t, "3.54")))
+
+	store := newTestStore(t)
+	installer, addon := newTestInstaller(t, client, "3.53")
+	installer.Store = store
+
+	lock := &Lockfile{Addons: []LockedAddon{{
+		ID:      "3",
+		Flavor:  FlavorRetail,
+		Version: "3.53",
+		SHA256:  sha256Hex(addonZip(t, "3.53")),
+		URL:     *addon.URL,
+	}}}
+
+	_, err := installer.InstallLocked(context.Background(), lock)
+	if !errors.Is(err, ErrLockMismatch) {
+		t.Errorf("Installer.InstallLocked() returned %v, want %v", err, ErrLockMismatch)
+	}
+
+	if _, err := os.Stat(filepath.Dir(installer.addonsDir)); !os.IsNotExist(err) {
+		t.Errorf("Installer.InstallLocked() created the game directory: %v", err)
+	}
+
+	if _, err := store.Lookup("3", "3.53"); !errors.Is(err, ErrNotStored) {
+		t.Errorf("Installer.InstallLocked() stored the mismatching file: %v", err)
+	}
+}
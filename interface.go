@@ -0,0 +1,82 @@
+package tukui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNoPatch is returned for an Addon without a patch.
+var ErrNoPatch = errors.New("addon has no patch")
+
+// PatchToInterface converts a patch string like 7.2.5 or 8.3 into the interface number
+// used by .toc files and the game client, e.g. 70205 or 80300.
+func PatchToInterface(patch string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(patch), ".")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid patch %q", patch)
+	}
+
+	var numbers [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || (i > 0 && n > 99) {
+			return 0, fmt.Errorf("invalid patch %q", patch)
+		}
+		numbers[i] = n
+	}
+
+	if numbers[0] == 0 {
+		return 0, fmt.Errorf("invalid patch %q", patch)
+	}
+
+	return numbers[0]*10000 + numbers[1]*100 + numbers[2], nil
+}
+
+// InterfaceToPatch converts an interface number like 70205 into the patch string 7.2.5.
+func InterfaceToPatch(iface int) string {
+	return fmt.Sprintf("%d.%d.%d", iface/10000, iface/100%100, iface%100)
+}
+
+// InterfaceFlavor returns the flavor of the game client using the given interface
+// number. Classic clients use patch numbers the original expansions never had,
+// e.g. 1.13 and later for Classic Era or 3.4 for Wrath Classic.
+func InterfaceFlavor(iface int) Flavor {
+	major, minor := iface/10000, iface/100%100
+
+	switch {
+	case major == 1 && minor >= 13:
+		return FlavorClassic
+	case major == 2 && minor >= 5:
+		return FlavorBurningCrusade
+	case major == 3 && minor >= 4:
+		return FlavorWrath
+	case major == 4 && minor >= 4:
+		return FlavorCataclysm
+	}
+
+	return FlavorRetail
+}
+
+// IsCompatible reports whether the addon targets the game client with the given
+// interface number. The addon must be made for the flavor of the client and for its
+// patch or a newer one; the last digit of the patch is ignored like the game client does.
+// An addon for an older patch is reported as incompatible, the game client considers
+// it out of date.
+func IsCompatible(addon Addon, clientInterface int) (bool, error) {
+	if addon.Patch == nil || *addon.Patch == "" {
+		return false, ErrNoPatch
+	}
+
+	iface, err := PatchToInterface(*addon.Patch)
+	if err != nil {
+		return false, err
+	}
+
+	if InterfaceFlavor(iface) != InterfaceFlavor(clientInterface) {
+		return false, nil
+	}
+
+	return iface/100 >= clientInterface/100, nil
+}
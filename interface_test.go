@@ -0,0 +1,110 @@
+package tukui
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPatchToInterface(t *testing.T) {
+	tests := map[string]int{
+		"7.2.5":  70205,
+		"8.3":    80300,
+		"1.13.4": 11304,
+		"10.2.7": 100207,
+		"9":      90000,
+	}
+
+	for patch, want := range tests {
+		got, err := PatchToInterface(patch)
+		if err != nil {
+			t.Errorf("PatchToInterface(%q) returned error: %v", patch, err)
+		}
+
+		if got != want {
+			t.Errorf("PatchToInterface(%q) returned %d, want %d", patch, got, want)
+		}
+	}
+}
+
+func TestPatchToInterface_Invalid(t *testing.T) {
+	for _, patch := range []string{"", "0.1", "7.2.5.1", "7.100", "8.x", "-1.2"} {
+		if _, err := PatchToInterface(patch); err == nil {
+			t.Errorf("PatchToInterface(%q) returned no error", patch)
+		}
+	}
+}
+
+func TestInterfaceToPatch(t *testing.T) {
+	tests := map[int]string{
+		70205:  "7.2.5",
+		80300:  "8.3.0",
+		11304:  "1.13.4",
+		100207: "10.2.7",
+	}
+
+	for iface, want := range tests {
+		if got := InterfaceToPatch(iface); got != want {
+			t.Errorf("InterfaceToPatch(%d) returned %q, want %q", iface, got, want)
+		}
+	}
+}
+
+func TestInterfaceFlavor(t *testing.T) {
+	tests := map[int]Flavor{
+		11200:  FlavorRetail,
+		11304:  FlavorClassic,
+		11502:  FlavorClassic,
+		20504:  FlavorBurningCrusade,
+		30403:  FlavorWrath,
+		30300:  FlavorRetail,
+		40400:  FlavorCataclysm,
+		70205:  FlavorRetail,
+		100207: FlavorRetail,
+	}
+
+	for iface, want := range tests {
+		if got := InterfaceFlavor(iface); got != want {
+			t.Errorf("InterfaceFlavor(%d) returned %q, want %q", iface, got, want)
+		}
+	}
+}
+
+func TestIsCompatible(t *testing.T) {
+	tests := []struct {
+		patch  string
+		client int
+		want   bool
+	}{
+		{"8.3", 80300, true},
+		{"8.3.0", 80307, true},
+		{"8.3", 90002, false},
+		{"9.0.2", 80300, true},
+		{"7.2.5", 80300, false},
+		{"1.13.5", 11305, true},
+		{"1.13.4", 11307, true},
+		{"1.13.4", 11400, false},
+		{"1.13.5", 80300, false},
+		{"8.3", 11305, false},
+	}
+
+	for _, tt := range tests {
+		got, err := IsCompatible(Addon{Patch: String(tt.patch)}, tt.client)
+		if err != nil {
+			t.Errorf("IsCompatible(%q, %d) returned error: %v", tt.patch, tt.client, err)
+		}
+
+		if got != tt.want {
+			t.Errorf("IsCompatible(%q, %d) returned %v, want %v", tt.patch, tt.client, got, tt.want)
+		}
+	}
+}
+
+func TestIsCompatible_NoPatch(t *testing.T) {
+	if _, err := IsCompatible(Addon{}, 80300); !errors.Is(err, ErrNoPatch) {
+		t.Errorf("IsCompatible() returned %v, want %v", err, ErrNoPatch)
+	}
+
+	if _, err := IsCompatible(Addon{Patch: String("latest")}, 80300); err == nil {
+		t.Errorf("IsCompatible() returned no error")
+	}
+}
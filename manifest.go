@@ -0,0 +1,169 @@
+package tukui
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+)
+
+// ManifestFile is the name of the manifest the Installer keeps next to the AddOns directory.
+const ManifestFile = "tukui-manifest.json"
+
+// A Manifest records the addons installed by an Installer.
+type Manifest struct {
+	// Addons are the installed addons sorted by ID
+	Addons []ManifestEntry `json:"addons"`
+}
+
+// A ManifestEntry describes an installed addon.
+type ManifestEntry struct {
+	// ID of the addon in the catalog
+	ID string `json:"id"`
+	// Name of the addon
+	Name string `json:"name,omitempty"`
+	// Flavor of the game client the addon was installed for
+	Flavor Flavor `json:"flavor"`
+	// Version of the addon
+	Version string `json:"version"`
+	// SHA256 is the hex encoded SHA-256 checksum of the .zip file
+	SHA256 string `json:"sha256"`
+	// URL the .zip file was downloaded from
+	URL string `json:"url,omitempty"`
+	// Folders created in the AddOns directory
+	Folders []string `json:"folders"`
+	// InstalledAt is the time of the installation
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// ReadManifest reads the manifest at path. A missing file is an empty Manifest.
+func ReadManifest(path string) (*Manifest, error) {
+	var m Manifest
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &m, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// Write replaces the file at path atomically with the manifest.
+func (m *Manifest) Write(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// Entry returns the entry of the addon with the given ID.
+func (m *Manifest) Entry(id string) (ManifestEntry, bool) {
+	for _, entry := range m.Addons {
+		if entry.ID == id {
+			return entry, true
+		}
+	}
+
+	return ManifestEntry{}, false
+}
+
+// set adds the entry or replaces the entry with the same ID.
+func (m *Manifest) set(entry ManifestEntry) {
+	for i := range m.Addons {
+		if m.Addons[i].ID == entry.ID {
+			m.Addons[i] = entry
+			return
+		}
+	}
+
+	m.Addons = append(m.Addons, entry)
+	sort.Slice(m.Addons, func(i, j int) bool {
+		return m.Addons[i].ID < m.Addons[j].ID
+	})
+}
+
+// remove removes the entry with the given ID.
+func (m *Manifest) remove(id string) {
+	for i := range m.Addons {
+		if m.Addons[i].ID == id {
+			m.Addons = append(m.Addons[:i], m.Addons[i+1:]...)
+			return
+		}
+	}
+}
+
+// Lock returns a Lockfile pinning the installed versions of all addons.
+func (m *Manifest) Lock() *Lockfile {
+	lock := &Lockfile{}
+	for _, entry := range m.Addons {
+		lock.Addons = append(lock.Addons, LockedAddon{
+			ID:      entry.ID,
+			Name:    entry.Name,
+			Flavor:  entry.Flavor,
+			Version: entry.Version,
+			SHA256:  entry.SHA256,
+			URL:     entry.URL,
+		})
+	}
+
+	return lock
+}
+
+// A Lockfile pins a set of addons to exact versions, so the same set can be installed
+// on another machine with Installer.InstallLocked.
+type Lockfile struct {
+	// Addons are the pinned addons
+	Addons []LockedAddon `json:"addons"`
+}
+
+// A LockedAddon is an addon pinned to a version and the checksum of its .zip file.
+type LockedAddon struct {
+	// ID of the addon in the catalog
+	ID string `json:"id"`
+	// Name of the addon
+	Name string `json:"name,omitempty"`
+	// Flavor of the game client
+	Flavor Flavor `json:"flavor"`
+	// Version of the addon
+	Version string `json:"version"`
+	// SHA256 is the hex encoded SHA-256 checksum of the .zip file
+	SHA256 string `json:"sha256"`
+	// URL of the .zip file
+	URL string `json:"url,omitempty"`
+}
+
+// ReadLockfile reads the lockfile at path.
+func ReadLockfile(path string) (*Lockfile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock Lockfile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	return &lock, nil
+}
+
+// Write replaces the file at path atomically with the lockfile.
+func (l *Lockfile) Write(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, append(data, '\n'))
+}
@@ -0,0 +1,400 @@
+package tukui
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrDependencyCycle is returned if addons depend on each other.
+	ErrDependencyCycle = errors.New("dependency cycle")
+	// ErrMissingDependency is returned if a required dependency is neither installed nor in the catalog.
+	ErrMissingDependency = errors.New("missing dependency")
+)
+
+// ZipDependencies reads the .toc files the game client of the given flavor loads from
+// the addon .zip file at zipPath. It returns the top-level folders of the file and the
+// required dependencies, read from the Dependencies and RequiredDeps fields, on folders
+// that are not part of the file itself.
+func ZipDependencies(zipPath string, flavor Flavor) (folders, deps []string, err error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+
+	tocs := make(map[string]*zip.File)
+	ranks := make(map[string]int)
+	seen := make(map[string]bool)
+
+	for _, f := range r.File {
+		name, err := zipEntryName(f)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		parts := strings.Split(name, "/")
+		if name == "" || len(parts) < 2 {
+			continue
+		}
+
+		folder := parts[0]
+		if !seen[folder] {
+			seen[folder] = true
+			folders = append(folders, folder)
+		}
+
+		if len(parts) != 2 || !strings.EqualFold(filepath.Ext(parts[1]), ".toc") {
+			continue
+		}
+
+		tocName, tocFlavor := ParseTOCName(parts[1])
+		if !strings.EqualFold(tocName, folder) {
+			continue
+		}
+
+		if rank := tocRank(tocFlavor, flavor, parts[1]); rank > ranks[folder] {
+			tocs[folder], ranks[folder] = f, rank
+		}
+	}
+
+	sort.Strings(folders)
+
+	own := make(map[string]bool)
+	for _, folder := range folders {
+		own[strings.ToLower(folder)] = true
+	}
+
+	for _, folder := range folders {
+		f, ok := tocs[folder]
+		if !ok {
+			continue
+		}
+
+		toc, err := readZipTOC(f)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+
+		for _, dep := range toc.Dependencies {
+			if !own[strings.ToLower(dep)] {
+				own[strings.ToLower(dep)] = true
+				deps = append(deps, dep)
+			}
+		}
+	}
+
+	return folders, deps, nil
+}
+
+func readZipTOC(f *zip.File) (*TOC, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	toc, err := ParseTOC(rc)
+	if err != nil {
+		return nil, err
+	}
+	toc.Name, toc.Flavor = ParseTOCName(filepath.Base(f.Name))
+
+	return toc, nil
+}
+
+// A ResolvedAddon is an addon to install together with the content of its .zip file.
+type ResolvedAddon struct {
+	// Addon of the catalog
+	Addon Addon
+	// Path of the downloaded .zip file
+	Path string
+	// Folders of the .zip file
+	Folders []string
+	// Dependencies are the required folders of other addons
+	Dependencies []string
+}
+
+// A MissingDependency is a required folder neither installed nor found in the catalog.
+type MissingDependency struct {
+	// Addon requiring the folder
+	Addon Addon
+	// Folder that is required
+	Folder string
+}
+
+// A Resolver finds the dependencies of addons and the order to install them in.
+type Resolver struct {
+	// Catalog to look up dependencies in, including the UI suites
+	Catalog []Addon
+	// Flavor of the game client selecting the .toc files
+	Flavor Flavor
+	// Installed are the folders of the AddOns directory, which satisfy dependencies
+	// without installing anything
+	Installed []string
+	// Fetch writes the .zip file of the addon to path
+	Fetch func(ctx context.Context, addon Addon, path string) error
+	// Dir holds the fetched .zip files
+	Dir string
+}
+
+// Resolve fetches the given addons and, recursively, the catalog addons their .toc
+// files require. Dependencies are folder names, they are looked up in the catalog by
+// the Directories of the addons or by comparing them with the normalized addon names.
+// Blizzard_ folders are part of the game and always available.
+//
+// The returned addons are ordered so that every addon comes after its dependencies.
+// If a dependency cannot be satisfied, the missing dependencies are returned together
+// with an error wrapping ErrMissingDependency. Dependencies between the addons forming
+// a cycle return ErrDependencyCycle.
+func (r *Resolver) Resolve(ctx context.Context, addons ...Addon) ([]ResolvedAddon, []MissingDependency, error) {
+	installed := make(map[string]bool)
+	for _, folder := range r.Installed {
+		installed[strings.ToLower(folder)] = true
+	}
+
+	var resolved []ResolvedAddon
+	queued := make(map[string]bool)
+	queue := append([]Addon(nil), addons...)
+	for _, addon := range queue {
+		queued[stringValue(addon.Id)] = true
+	}
+
+	for len(queue) > 0 {
+		addon := queue[0]
+		queue = queue[1:]
+
+		if !isFolderName(stringValue(addon.Id)) {
+			return nil, nil, errors.New("addon has no id")
+		}
+
+		path := filepath.Join(r.Dir, *addon.Id+".zip")
+		if err := r.Fetch(ctx, addon, path); err != nil {
+			return nil, nil, fmt.Errorf("fetching %s: %w", stringValue(addon.Name), err)
+		}
+
+		folders, deps, err := ZipDependencies(path, r.Flavor)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s: %w", stringValue(addon.Name), err)
+		}
+
+		resolved = append(resolved, ResolvedAddon{Addon: addon, Path: path, Folders: folders, Dependencies: deps})
+
+		for _, dep := range deps {
+			if installed[strings.ToLower(dep)] || isBlizzardFolder(dep) {
+				continue
+			}
+
+			if match := r.lookup(dep); match != nil && !queued[*match.Id] {
+				queued[*match.Id] = true
+				queue = append(queue, *match)
+			}
+		}
+	}
+
+	provider := make(map[string]int)
+	for n, addon := range resolved {
+		for _, folder := range addon.Folders {
+			provider[strings.ToLower(folder)] = n
+		}
+	}
+
+	var missing []MissingDependency
+	for _, addon := range resolved {
+		for _, dep := range addon.Dependencies {
+			_, ok := provider[strings.ToLower(dep)]
+			if !ok && !installed[strings.ToLower(dep)] && !isBlizzardFolder(dep) {
+				missing = append(missing, MissingDependency{Addon: addon.Addon, Folder: dep})
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for _, m := range missing {
+			names = append(names, fmt.Sprintf("%s requires %s", stringValue(m.Addon.Name), m.Folder))
+		}
+
+		return nil, missing, fmt.Errorf("%w: %s", ErrMissingDependency, strings.Join(names, ", "))
+	}
+
+	order, err := sortResolved(resolved, provider)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return order, nil, nil
+}
+
+// lookup returns the catalog addon shipping the folder. Addons listing their
+// Directories are matched by every folder of the list, so a dependency on a
+// sub-folder like ElvUI_Libraries finds ElvUI. Other addons are matched by name.
+func (r *Resolver) lookup(folder string) *Addon {
+	for n := range r.Catalog {
+		if r.Catalog[n].Id == nil {
+			continue
+		}
+
+		for _, dir := range r.Catalog[n].Directories {
+			if strings.EqualFold(dir, folder) {
+				return &r.Catalog[n]
+			}
+		}
+	}
+
+	name := normalizeName(folder)
+	for n := range r.Catalog {
+		if r.Catalog[n].Id != nil && r.Catalog[n].Name != nil && normalizeName(*r.Catalog[n].Name) == name {
+			return &r.Catalog[n]
+		}
+	}
+
+	return nil
+}
+
+func isBlizzardFolder(name string) bool {
+	return strings.HasPrefix(strings.ToLower(name), "blizzard_")
+}
+
+// sortResolved orders the addons topologically, dependencies first.
+func sortResolved(resolved []ResolvedAddon, provider map[string]int) ([]ResolvedAddon, error) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+
+	state := make([]int, len(resolved))
+	order := make([]ResolvedAddon, 0, len(resolved))
+	var path []string
+
+	var visit func(n int) error
+	visit = func(n int) error {
+		name := stringValue(resolved[n].Addon.Name)
+
+		switch state[n] {
+		case done:
+			return nil
+		case visiting:
+			start := 0
+			for start < len(path) && path[start] != name {
+				start++
+			}
+			cycle := append(append([]string(nil), path[start:]...), name)
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, " -> "))
+		}
+
+		state[n] = visiting
+		path = append(path, name)
+
+		for _, dep := range resolved[n].Dependencies {
+			if m, ok := provider[strings.ToLower(dep)]; ok && m != n {
+				if err := visit(m); err != nil {
+					return err
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[n] = done
+		order = append(order, resolved[n])
+
+		return nil
+	}
+
+	for n := range resolved {
+		if err := visit(n); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// InstallWithDependencies installs the addon together with the catalog addons it
+// requires, in dependency order. All .zip files are downloaded and their dependencies
+// checked before anything is installed. Dependencies already present in the AddOns
+// directory are not installed again.
+func (i *Installer) InstallWithDependencies(ctx context.Context, addon Addon, catalog []Addon) ([]ManifestEntry, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	manifest, err := i.Manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	var installed []string
+	entries, err := ioutil.ReadDir(i.addonsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			installed = append(installed, entry.Name())
+		}
+	}
+
+	// the folders of the addon itself are replaced and do not satisfy its dependencies
+	if own, ok := manifest.Entry(stringValue(addon.Id)); ok {
+		installed = removeFolders(installed, own.Folders)
+	}
+
+	tmp, err := ioutil.TempDir("", "tukui-install-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	results := make(map[string]DownloadResult)
+	resolver := &Resolver{
+		Catalog:   catalog,
+		Flavor:    i.flavor,
+		Installed: installed,
+		Dir:       tmp,
+		Fetch: func(ctx context.Context, addon Addon, path string) error {
+			result, err := i.fetch(ctx, addon, path, "")
+			results[path] = result
+			return err
+		},
+	}
+
+	order, _, err := resolver.Resolve(ctx, addon)
+	if err != nil {
+		return nil, err
+	}
+
+	var installedEntries []ManifestEntry
+	for _, r := range order {
+		entry, err := i.installFile(manifest, r.Addon, r.Path, results[r.Path])
+		if err != nil {
+			return installedEntries, err
+		}
+
+		installedEntries = append(installedEntries, entry)
+	}
+
+	return installedEntries, nil
+}
+
+func removeFolders(folders, remove []string) []string {
+	drop := make(map[string]bool)
+	for _, folder := range remove {
+		drop[strings.ToLower(folder)] = true
+	}
+
+	var kept []string
+	for _, folder := range folders {
+		if !drop[strings.ToLower(folder)] {
+			kept = append(kept, folder)
+		}
+	}
+
+	return kept
+}
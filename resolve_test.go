@@ -0,0 +1,258 @@
+package tukui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func tocZip(t *testing.T, folder, tocFields string) []byte {
+	return buildZip(t, zipEntry{name: folder + "/" + folder + ".toc", content: tocFields})
+}
+
+// fetchFrom returns a Resolver.Fetch function serving the .zip files by addon ID.
+func fetchFrom(t *testing.T, zips map[string][]byte, fetched *[]string) func(context.Context, Addon, string) error {
+	return func(ctx context.Context, addon Addon, path string) error {
+		*fetched = append(*fetched, *addon.Id)
+		data, ok := zips[*addon.Id]
+		if !ok {
+			t.Fatalf("unexpected fetch of %s", *addon.Id)
+		}
+
+		return ioutil.WriteFile(path, data, 0644)
+	}
+}
+
+func resolvedNames(resolved []ResolvedAddon) []string {
+	var names []string
+	for _, r := range resolved {
+		names = append(names, *r.Addon.Name)
+	}
+
+	return names
+}
+
+func TestZipDependencies(t *testing.T) {
+	path := writeZip(t, tempDir(t),
+		zipEntry{name: "ElvUI_SLE/ElvUI_SLE.toc", content: "## RequiredDeps: ElvUI, ElvUI_Libraries\n## OptionalDeps: Details\n"},
+		zipEntry{name: "ElvUI_SLE/ElvUI_SLE_Vanilla.toc", content: "## Dependencies: ElvUI_Classic\n"},
+		zipEntry{name: "ElvUI_SLE/Core/Core.lua", content: ""},
+		zipEntry{name: "ElvUI_SLE_Options/ElvUI_SLE_Options.toc", content: "## Dependencies: ElvUI_SLE, elvui, Blizzard_Settings\n"},
+	)
+
+	tests := []struct {
+		flavor Flavor
+		deps   []string
+	}{
+		{FlavorRetail, []string{"ElvUI", "ElvUI_Libraries", "Blizzard_Settings"}},
+		{FlavorClassic, []string{"ElvUI_Classic", "elvui", "Blizzard_Settings"}},
+	}
+
+	for _, tt := range tests {
+		folders, deps, err := ZipDependencies(path, tt.flavor)
+		if err != nil {
+			t.Fatalf("ZipDependencies() returned error: %v", err)
+		}
+
+		if want := []string{"ElvUI_SLE", "ElvUI_SLE_Options"}; !cmp.Equal(folders, want) {
+			t.Errorf("ZipDependencies() returned folders %v, want %v", folders, want)
+		}
+
+		if !cmp.Equal(deps, tt.deps) {
+			t.Errorf("ZipDependencies() for %s returned %v, want %v", tt.flavor, deps, tt.deps)
+		}
+	}
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	catalog := []Addon{
+		{Id: String("-2"), Name: String("ElvUI")},
+		{Id: String("3"), Name: String("AddOnSkins")},
+		{Id: String("10"), Name: String("ElvUI Shadow & Light")},
+		{Id: String("11"), Name: String("Masque")},
+	}
+
+	zips := map[string][]byte{
+		"-2": buildZip(t,
+			zipEntry{name: "ElvUI/ElvUI.toc", content: "## RequiredDeps: ElvUI_Libraries\n"},
+			zipEntry{name: "ElvUI_Libraries/ElvUI_Libraries.toc"},
+		),
+		"3":  tocZip(t, "AddOnSkins", "## RequiredDeps: Masque, Details\n"),
+		"10": tocZip(t, "ElvUI_SLE", "## RequiredDeps: ElvUI, AddOnSkins, Blizzard_Settings\n"),
+		"11": tocZip(t, "Masque", ""),
+	}
+
+	var fetched []string
+	resolver := &Resolver{
+		Catalog:   catalog,
+		Installed: []string{"Details"},
+		Dir:       tempDir(t),
+		Fetch:     fetchFrom(t, zips, &fetched),
+	}
+
+	resolved, missing, err := resolver.Resolve(context.Background(), catalog[2])
+	if err != nil {
+		t.Fatalf("Resolver.Resolve() returned error: %v", err)
+	}
+
+	if missing != nil {
+		t.Errorf("Resolver.Resolve() returned missing dependencies %+v", missing)
+	}
+
+	want := []string{"ElvUI", "Masque", "AddOnSkins", "ElvUI Shadow & Light"}
+	if got := resolvedNames(resolved); !cmp.Equal(got, want) {
+		t.Errorf("Resolver.Resolve() returned order %v, want %v", got, want)
+	}
+
+	if want := []string{"10", "-2", "3", "11"}; !cmp.Equal(fetched, want) {
+		t.Errorf("Resolver.Resolve() fetched %v, want %v", fetched, want)
+	}
+}
+
+func TestResolver_Resolve_SubFolder(t *testing.T) {
+	catalog := []Addon{
+		{Id: String("-2"), Name: String("ElvUI"), Directories: []string{"ElvUI", "ElvUI_Options", "ElvUI_Libraries"}},
+		{Id: String("38"), Name: String("ElvUI WindTools")},
+	}
+
+	zips := map[string][]byte{
+		"-2": buildZip(t,
+			zipEntry{name: "ElvUI/ElvUI.toc", content: "## RequiredDeps: ElvUI_Libraries\n"},
+			zipEntry{name: "ElvUI_Libraries/ElvUI_Libraries.toc"},
+			zipEntry{name: "ElvUI_Options/ElvUI_Options.toc"},
+		),
+		"38": tocZip(t, "ElvUI_WindTools", "## RequiredDeps: elvui_libraries\n"),
+	}
+
+	var fetched []string
+	resolver := &Resolver{Catalog: catalog, Dir: tempDir(t), Fetch: fetchFrom(t, zips, &fetched)}
+
+	resolved, missing, err := resolver.Resolve(context.Background(), catalog[1])
+	if err != nil {
+		t.Fatalf("Resolver.Resolve() returned error: %v, missing %+v", err, missing)
+	}
+
+	want := []string{"ElvUI", "ElvUI WindTools"}
+	if got := resolvedNames(resolved); !cmp.Equal(got, want) {
+		t.Errorf("Resolver.Resolve() returned order %v, want %v", got, want)
+	}
+}
+
+func TestResolver_Resolve_Errors(t *testing.T) {
+	catalog := []Addon{
+		{Id: String("1"), Name: String("Alpha")},
+		{Id: String("2"), Name: String("Beta")},
+		{Id: String("3"), Name: String("Gamma")},
+	}
+
+	tests := []struct {
+		name    string
+		zips    map[string][]byte
+		want    error
+		missing []MissingDependency
+	}{
+		{
+			name: "cycle",
+			zips: map[string][]byte{
+				"1": tocZip(t, "Alpha", "## RequiredDeps: Beta\n"),
+				"2": tocZip(t, "Beta", "## RequiredDeps: Gamma\n"),
+				"3": tocZip(t, "Gamma", "## RequiredDeps: Beta\n"),
+			},
+			want: ErrDependencyCycle,
+		},
+		{
+			name: "missing",
+			zips: map[string][]byte{
+				"1": tocZip(t, "Alpha", "## RequiredDeps: Beta, WeakAuras\n"),
+				"2": tocZip(t, "Beta", "## RequiredDeps: LibStub\n"),
+			},
+			want: ErrMissingDependency,
+			missing: []MissingDependency{
+				{Addon: catalog[0], Folder: "WeakAuras"},
+				{Addon: catalog[1], Folder: "LibStub"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetched []string
+			resolver := &Resolver{Catalog: catalog, Dir: tempDir(t), Fetch: fetchFrom(t, tt.zips, &fetched)}
+
+			resolved, missing, err := resolver.Resolve(context.Background(), catalog[0])
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Resolver.Resolve() returned error %v, want %v", err, tt.want)
+			}
+
+			if resolved != nil {
+				t.Errorf("Resolver.Resolve() returned %v", resolvedNames(resolved))
+			}
+
+			if !cmp.Equal(missing, tt.missing) {
+				t.Errorf("Resolver.Resolve() returned missing %+v, want %+v", missing, tt.missing)
+			}
+		})
+	}
+}
+
+func TestInstaller_InstallWithDependencies(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	zips := map[string][]byte{
+		"-2": tocZip(t, "ElvUI", ""),
+		"3":  tocZip(t, "AddOnSkins", "## RequiredDeps: ElvUI\n"),
+		"4":  tocZip(t, "Broken", "## RequiredDeps: Unknown\n"),
+	}
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		serveZip(`"v"`, zips[r.URL.Query().Get("id")])(w, r)
+	})
+
+	var catalog []Addon
+	for _, a := range []struct{ id, name string }{{"-2", "ElvUI"}, {"3", "AddOnSkins"}, {"4", "Broken"}} {
+		catalog = append(catalog, Addon{
+			Id:      String(a.id),
+			Name:    String(a.name),
+			Version: String("1.0"),
+			URL:     String(fmt.Sprintf("%sdownload?id=%s", client.url, a.id)),
+		})
+	}
+
+	installer, _ := newTestInstaller(t, client, "1.0")
+
+	if _, err := installer.InstallWithDependencies(context.Background(), catalog[2], catalog); !errors.Is(err, ErrMissingDependency) {
+		t.Errorf("Installer.InstallWithDependencies() returned %v, want %v", err, ErrMissingDependency)
+	}
+
+	entries, err := installer.InstallWithDependencies(context.Background(), catalog[1], catalog)
+	if err != nil {
+		t.Fatalf("Installer.InstallWithDependencies() returned error: %v", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	if want := []string{"-2", "3"}; !cmp.Equal(ids, want) {
+		t.Errorf("Installer.InstallWithDependencies() installed %v, want %v", ids, want)
+	}
+
+	want := []string{"AddOnSkins/AddOnSkins.toc", "ElvUI/ElvUI.toc"}
+	if got := listFiles(t, installer.addonsDir); !cmp.Equal(got, want) {
+		t.Errorf("Installer.InstallWithDependencies() left %v, want %v", got, want)
+	}
+
+	manifest, err := installer.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(manifest.Addons) != 2 {
+		t.Errorf("Installer.InstallWithDependencies() recorded %+v", manifest.Addons)
+	}
+}
@@ -0,0 +1,313 @@
+package tukui
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// partialSuffix is appended to the destination path of an unfinished download
+const partialSuffix = ".part"
+
+// resumeSuffix is appended to the destination path for the validators of an unfinished download
+const resumeSuffix = ".part.json"
+
+// resumeState holds the validators of a partial download. They are sent with
+// If-Range so that the server only continues a download of the same file.
+type resumeState struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// validator returns the value for the If-Range header. Weak ETags must not be
+// used for range requests, in that case Last-Modified is the only option.
+func (s resumeState) validator() string {
+	if s.ETag != "" && !strings.HasPrefix(s.ETag, "W/") {
+		return s.ETag
+	}
+
+	return s.LastModified
+}
+
+// DownloadFile downloads the .zip file of the given Addon to path. Data is first written
+// to path with a ".part" suffix and renamed once it is complete. If a previous call
+// was interrupted, the download is resumed with a HTTP Range request as long as the
+// server confirms via If-Range that the file did not change in the meantime.
+// Otherwise the file is downloaded from the beginning.
+//
+// The returned DownloadResult always describes the complete file.
+func (c *Client) DownloadFile(ctx context.Context, addon Addon, path string, opts *DownloadOptions) (DownloadResult, *http.Response, error) {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+
+	if addon.URL == nil || *addon.URL == "" {
+		return DownloadResult{}, nil, ErrNoDownloadURL
+	}
+
+	partPath := path + partialSuffix
+	statePath := path + resumeSuffix
+
+	if result, ok, err := fileFromStore(opts.Store, addon, path, partPath, statePath, opts.Progress); ok || err != nil {
+		return result, nil, err
+	}
+
+	offset, state := loadPartial(partPath, statePath, *addon.URL)
+
+	header := http.Header{}
+	if offset > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		header.Set("If-Range", state.validator())
+	}
+
+	resp, err := c.requestDownload(ctx, addon, header)
+	if err != nil {
+		return DownloadResult{}, resp, err
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// the server ignored the range or the file changed
+		offset = 0
+	case http.StatusPartialContent:
+		start, err := contentRangeStart(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			removePartial(partPath, statePath)
+			return DownloadResult{}, resp, fmt.Errorf("unexpected content range %q", resp.Header.Get("Content-Range"))
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// either the partial file is already complete or it is larger than the
+		// file on the server, which means it is not the same file anymore
+		if size, ok := contentRangeSize(resp.Header.Get("Content-Range")); ok && size == offset {
+			h := sha256.New()
+			if err := hashFile(partPath, h); err != nil {
+				return DownloadResult{}, resp, err
+			}
+
+			result, err := completeDownload(opts.Store, addon, partPath, statePath, path, offset, h)
+			return result, resp, err
+		}
+
+		removePartial(partPath, statePath)
+		resp.Body.Close()
+
+		return c.DownloadFile(ctx, addon, path, opts)
+	default:
+		return DownloadResult{}, resp, unexpectedStatus(resp)
+	}
+
+	if err := checkZipContentType(resp); err != nil {
+		return DownloadResult{}, resp, err
+	}
+
+	if offset == 0 {
+		state = resumeState{
+			URL:          *addon.URL,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+
+		if err := saveResumeState(statePath, state); err != nil {
+			return DownloadResult{}, resp, err
+		}
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return DownloadResult{}, resp, err
+	}
+
+	h := sha256.New()
+	if offset > 0 {
+		if err := hashFile(partPath, h); err != nil {
+			f.Close()
+			return DownloadResult{}, resp, err
+		}
+	}
+
+	n, err := copyWithProgress(io.MultiWriter(f, h), resp.Body, offset, resp.ContentLength, opts.Progress)
+	if err != nil {
+		f.Close()
+		return DownloadResult{}, resp, err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return DownloadResult{}, resp, err
+	}
+
+	if err := f.Close(); err != nil {
+		return DownloadResult{}, resp, err
+	}
+
+	result, err := completeDownload(opts.Store, addon, partPath, statePath, path, offset+n, h)
+
+	return result, resp, err
+}
+
+// completeDownload renames the complete partial download to path and adds it to
+// the store. The partial file only exists after the content type of its first
+// response was checked, so an already complete file needs no further checks.
+func completeDownload(store *Store, addon Addon, partPath, statePath, path string, size int64, h hash.Hash) (DownloadResult, error) {
+	if err := os.Rename(partPath, path); err != nil {
+		return DownloadResult{}, err
+	}
+
+	os.Remove(statePath)
+
+	result := DownloadResult{Size: size, SHA256: hexSum(h)}
+
+	return result, addFileToStore(store, addon, path)
+}
+
+// fileFromStore copies the stored .zip file of the addon to path. The file is
+// looked up before anything is written, so a partial download of a file that is
+// not stored is kept for resuming. A stored file replaces the partial download.
+func fileFromStore(store *Store, addon Addon, path, partPath, statePath string, progress func(written, total int64)) (DownloadResult, bool, error) {
+	id, version, ok := storeKey(addon)
+	if store == nil || !ok {
+		return DownloadResult{}, false, nil
+	}
+
+	if _, err := store.Lookup(id, version); errors.Is(err, ErrNotStored) {
+		return DownloadResult{}, false, nil
+	} else if err != nil {
+		return DownloadResult{}, false, err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".store-")
+	if err != nil {
+		return DownloadResult{}, false, err
+	}
+
+	result, ok, err := fromStore(store, addon, f, progress)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if !ok || err != nil {
+		os.Remove(f.Name())
+		return DownloadResult{}, false, err
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return DownloadResult{}, false, err
+	}
+
+	removePartial(partPath, statePath)
+
+	return result, true, nil
+}
+
+func addFileToStore(store *Store, addon Addon, path string) error {
+	id, version, ok := storeKey(addon)
+	if store == nil || !ok {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = store.Put(id, version, f)
+
+	return err
+}
+
+// loadPartial returns the size of the partial download and its validators. A
+// partial file without usable validators is discarded.
+func loadPartial(partPath, statePath, url string) (int64, resumeState) {
+	var state resumeState
+
+	info, err := os.Stat(partPath)
+	if err != nil || info.Size() == 0 {
+		return 0, state
+	}
+
+	data, err := ioutil.ReadFile(statePath)
+	if err == nil {
+		err = json.Unmarshal(data, &state)
+	}
+
+	if err != nil || state.URL != url || state.validator() == "" {
+		removePartial(partPath, statePath)
+		return 0, resumeState{}
+	}
+
+	return info.Size(), state
+}
+
+func saveResumeState(path string, state resumeState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func removePartial(partPath, statePath string) {
+	os.Remove(partPath)
+	os.Remove(statePath)
+}
+
+func hashFile(path string, w io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+
+	return err
+}
+
+// contentRangeStart returns the first byte position of a Content-Range header
+// like "bytes 100-199/200".
+func contentRangeStart(header string) (int64, error) {
+	if !strings.HasPrefix(header, "bytes ") {
+		return 0, errors.New("invalid content range")
+	}
+
+	dash := strings.IndexByte(header, '-')
+	if dash < 0 {
+		return 0, errors.New("invalid content range")
+	}
+
+	return strconv.ParseInt(header[len("bytes "):dash], 10, 64)
+}
+
+// contentRangeSize returns the complete length of a Content-Range header
+// like "bytes */200".
+func contentRangeSize(header string) (int64, bool) {
+	slash := strings.LastIndexByte(header, '/')
+	if !strings.HasPrefix(header, "bytes ") || slash < 0 {
+		return 0, false
+	}
+
+	size, err := strconv.ParseInt(header[slash+1:], 10, 64)
+
+	return size, err == nil
+}
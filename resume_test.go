@@ -0,0 +1,313 @@
+package tukui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var resumePayload = bytes.Repeat([]byte("0123456789"), 5000)
+
+func serveZip(etag string, payload []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "addon.zip", time.Date(2020, 9, 21, 0, 0, 0, 0, time.UTC), bytes.NewReader(payload))
+	}
+}
+
+func writePartial(t *testing.T, path string, data []byte, state resumeState) {
+	t.Helper()
+	if err := ioutil.WriteFile(path+partialSuffix, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveResumeState(path+resumeSuffix, state); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testDownloadedFile(t *testing.T, path string, result DownloadResult, want []byte) {
+	t.Helper()
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading downloaded file: %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("downloaded file has %d bytes, want %d", len(got), len(want))
+	}
+
+	wantResult := DownloadResult{Size: int64(len(want)), SHA256: sha256Hex(want)}
+	if !cmp.Equal(result, wantResult) {
+		t.Errorf("Client.DownloadFile() returned %+v, want %+v", result, wantResult)
+	}
+
+	for _, suffix := range []string{partialSuffix, resumeSuffix} {
+		if _, err := os.Stat(path + suffix); !os.IsNotExist(err) {
+			t.Errorf("%s file still exists after download", suffix)
+		}
+	}
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "tukui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return dir
+}
+
+func TestClient_DownloadFile(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			t.Errorf("Request has Range header %q, want none", r.Header.Get("Range"))
+		}
+		serveZip(`"v1"`, resumePayload)(w, r)
+	})
+
+	path := filepath.Join(tempDir(t), "addon.zip")
+	addon := Addon{URL: String(client.url + "download")}
+	result, _, err := client.DownloadFile(context.Background(), addon, path, nil)
+	if err != nil {
+		t.Fatalf("Client.DownloadFile() returned error: %v", err)
+	}
+
+	testDownloadedFile(t, path, result, resumePayload)
+}
+
+func TestClient_DownloadFile_Resume(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("Range"), "bytes=1000-"; got != want {
+			t.Errorf("Request Range header %q, want %q", got, want)
+		}
+		if got, want := r.Header.Get("If-Range"), `"v1"`; got != want {
+			t.Errorf("Request If-Range header %q, want %q", got, want)
+		}
+		serveZip(`"v1"`, resumePayload)(w, r)
+	})
+
+	path := filepath.Join(tempDir(t), "addon.zip")
+	addon := Addon{URL: String(client.url + "download")}
+	writePartial(t, path, resumePayload[:1000], resumeState{URL: *addon.URL, ETag: `"v1"`})
+
+	var first int64 = -1
+	opts := &DownloadOptions{
+		Progress: func(written, total int64) {
+			if first < 0 {
+				first = written
+			}
+		},
+	}
+
+	result, resp, err := client.DownloadFile(context.Background(), addon, path, opts)
+	if err != nil {
+		t.Fatalf("Client.DownloadFile() returned error: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusPartialContent {
+		t.Errorf("Client.DownloadFile() response status %d, want %d", resp.StatusCode, http.StatusPartialContent)
+	}
+
+	if first <= 1000 {
+		t.Errorf("Progress started at %d, want more than the partial size", first)
+	}
+
+	testDownloadedFile(t, path, result, resumePayload)
+}
+
+func TestClient_DownloadFile_ResumeWithStore(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	var requests int
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if got, want := r.Header.Get("Range"), "bytes=5000-"; got != want {
+			t.Errorf("Request Range header %q, want %q", got, want)
+		}
+		serveZip(`"v1"`, resumePayload)(w, r)
+	})
+
+	dir := tempDir(t)
+	store, err := NewStore(filepath.Join(dir, "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "addon.zip")
+	addon := Addon{Id: String("3"), Version: String("1.0"), URL: String(client.url + "download")}
+	writePartial(t, path, resumePayload[:5000], resumeState{URL: *addon.URL, ETag: `"v1"`})
+
+	result, _, err := client.DownloadFile(context.Background(), addon, path, &DownloadOptions{Store: store})
+	if err != nil {
+		t.Fatalf("Client.DownloadFile() returned error: %v", err)
+	}
+
+	testDownloadedFile(t, path, result, resumePayload)
+
+	// the completed download is stored and replaces a later partial download
+	os.Remove(path)
+	writePartial(t, path, resumePayload[:5000], resumeState{URL: *addon.URL, ETag: `"v1"`})
+
+	result, _, err = client.DownloadFile(context.Background(), addon, path, &DownloadOptions{Store: store})
+	if err != nil {
+		t.Fatalf("Client.DownloadFile() from the store returned error: %v", err)
+	}
+
+	if !result.Cached || requests != 1 {
+		t.Errorf("Client.DownloadFile() returned %+v after %d requests, want a cached result after 1", result, requests)
+	}
+
+	result.Cached = false
+	testDownloadedFile(t, path, result, resumePayload)
+}
+
+func TestClient_DownloadFile_Changed(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/download", serveZip(`"v2"`, resumePayload))
+
+	path := filepath.Join(tempDir(t), "addon.zip")
+	addon := Addon{URL: String(client.url + "download")}
+	writePartial(t, path, []byte("stale data of an older version"), resumeState{URL: *addon.URL, ETag: `"v1"`})
+
+	result, resp, err := client.DownloadFile(context.Background(), addon, path, nil)
+	if err != nil {
+		t.Fatalf("Client.DownloadFile() returned error: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Client.DownloadFile() response status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	testDownloadedFile(t, path, result, resumePayload)
+}
+
+func TestClient_DownloadFile_RangeIgnored(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Write(resumePayload)
+	})
+
+	path := filepath.Join(tempDir(t), "addon.zip")
+	addon := Addon{URL: String(client.url + "download")}
+	writePartial(t, path, resumePayload[:1000], resumeState{URL: *addon.URL, LastModified: "Mon, 21 Sep 2020 00:00:00 GMT"})
+
+	result, _, err := client.DownloadFile(context.Background(), addon, path, nil)
+	if err != nil {
+		t.Fatalf("Client.DownloadFile() returned error: %v", err)
+	}
+
+	testDownloadedFile(t, path, result, resumePayload)
+}
+
+func TestClient_DownloadFile_AlreadyComplete(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	var requests int
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		serveZip(`"v1"`, resumePayload)(w, r)
+	})
+
+	dir := tempDir(t)
+	store, err := NewStore(filepath.Join(dir, "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "addon.zip")
+	addon := Addon{Id: String("3"), Version: String("1.0"), URL: String(client.url + "download")}
+	writePartial(t, path, resumePayload, resumeState{URL: *addon.URL, ETag: `"v1"`})
+
+	result, resp, err := client.DownloadFile(context.Background(), addon, path, &DownloadOptions{Store: store})
+	if err != nil {
+		t.Fatalf("Client.DownloadFile() returned error: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable || requests != 1 {
+		t.Errorf("Client.DownloadFile() made %d requests with status %d, want 1 with %d", requests, resp.StatusCode, http.StatusRequestedRangeNotSatisfiable)
+	}
+
+	testDownloadedFile(t, path, result, resumePayload)
+
+	if _, err := store.Lookup("3", "1.0"); err != nil {
+		t.Errorf("Store.Lookup() of the completed download returned error: %v", err)
+	}
+}
+
+func TestClient_DownloadFile_PartialTooLarge(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	var requests int
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		serveZip(`"v1"`, resumePayload)(w, r)
+	})
+
+	path := filepath.Join(tempDir(t), "addon.zip")
+	addon := Addon{URL: String(client.url + "download")}
+	writePartial(t, path, append(resumePayload, 'x'), resumeState{URL: *addon.URL, ETag: `"v1"`})
+
+	result, _, err := client.DownloadFile(context.Background(), addon, path, nil)
+	if err != nil {
+		t.Fatalf("Client.DownloadFile() returned error: %v", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("Client.DownloadFile() made %d requests, want 2", requests)
+	}
+
+	testDownloadedFile(t, path, result, resumePayload)
+}
+
+func TestClient_DownloadFile_Interrupted(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Length", fmt.Sprint(len(resumePayload)))
+		w.Write(resumePayload[:2000])
+	})
+
+	path := filepath.Join(tempDir(t), "addon.zip")
+	addon := Addon{URL: String(client.url + "download")}
+	_, _, err := client.DownloadFile(context.Background(), addon, path, nil)
+	if err == nil {
+		t.Fatalf("Client.DownloadFile() returned no error")
+	}
+
+	offset, state := loadPartial(path+partialSuffix, path+resumeSuffix, *addon.URL)
+	if offset != 2000 || state.ETag != `"v1"` {
+		t.Errorf("partial download has %d bytes and ETag %q, want 2000 and %q", offset, state.ETag, `"v1"`)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("incomplete download was renamed to %s", path)
+	}
+}
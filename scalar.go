@@ -0,0 +1,233 @@
+package tukui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Scalar is a JSON scalar decoded as text, whatever its JSON type. The API is not
+// consistent about the types of its fields, e.g. the IDs of the UI suites are
+// numbers while all other IDs are strings.
+//
+// Strings are kept as they are, numbers keep their literal, e.g. "-2" or "1.10",
+// and booleans become "true" or "false". A null leaves a *Scalar nil. Objects and
+// arrays are an error.
+type Scalar string
+
+// UnmarshalJSON decodes any JSON scalar.
+func (s *Scalar) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	switch data[0] {
+	case '"':
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		*s = Scalar(str)
+	case '{', '[':
+		return fmt.Errorf("cannot decode %s into a scalar", kind(data[0]))
+	case 'n':
+		// null is a no-op like for the types of encoding/json
+	default:
+		// numbers and booleans are valid JSON literals at this point
+		*s = Scalar(data)
+	}
+
+	return nil
+}
+
+// ptr returns the Scalar as string pointer, which is nil for a nil Scalar.
+func (s *Scalar) ptr() *string {
+	if s == nil {
+		return nil
+	}
+
+	str := string(*s)
+	return &str
+}
+
+func kind(c byte) string {
+	if c == '{' {
+		return "object"
+	}
+
+	return "array"
+}
+
+// addonJSON decodes any record of the APIs. The UI suites of api.php name the last
+// download lastdownload, all other records last_download. The v1 API names the last
+// update last_update and lists the patches of all flavors in patch.
+type addonJSON struct {
+	Id             *Scalar         `json:"id"`
+	Name           *Scalar         `json:"name"`
+	SmallDesc      *Scalar         `json:"small_desc"`
+	Author         *Scalar         `json:"author"`
+	Version        *Scalar         `json:"version"`
+	ScreenshotUrl  *Scalar         `json:"screenshot_url"`
+	URL            *Scalar         `json:"url"`
+	Category       *Scalar         `json:"category"`
+	Downloads      *Scalar         `json:"downloads"`
+	LastUpdate     *Scalar         `json:"lastupdate"`
+	LastUpdateV1   *Scalar         `json:"last_update"`
+	Patch          json.RawMessage `json:"patch"`
+	WebUrl         *Scalar         `json:"web_url"`
+	LastDownload   *Scalar         `json:"last_download"`
+	LastDownloadUI *Scalar         `json:"lastdownload"`
+	DonateUrl      *Scalar         `json:"donate_url"`
+	Slug           *Scalar         `json:"slug"`
+	ChangelogUrl   *Scalar         `json:"changelog_url"`
+	TicketUrl      *Scalar         `json:"ticket_url"`
+	GitUrl         *Scalar         `json:"git_url"`
+	LogoUrl        *Scalar         `json:"logo_url"`
+	Directories    []Scalar        `json:"directories"`
+	Patches        []Scalar        `json:"patches"`
+}
+
+// knownFields are the JSON names of the fields of addonJSON.
+var knownFields = func() map[string]bool {
+	known := make(map[string]bool)
+
+	t := reflect.TypeOf(addonJSON{})
+	for i := 0; i < t.NumField(); i++ {
+		known[t.Field(i).Tag.Get("json")] = true
+	}
+
+	return known
+}()
+
+// UnmarshalJSON decodes an addon leniently, every field may be any JSON scalar.
+// Unknown fields are kept in Extra.
+func (a *Addon) UnmarshalJSON(data []byte) error {
+	var raw addonJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	var extra map[string]json.RawMessage
+	for key, value := range fields {
+		// encoding/json matches the names of fields case insensitively
+		if knownFields[strings.ToLower(key)] {
+			continue
+		}
+
+		if extra == nil {
+			extra = make(map[string]json.RawMessage)
+		}
+		extra[key] = value
+	}
+
+	lastDownload := raw.LastDownload
+	if lastDownload == nil {
+		lastDownload = raw.LastDownloadUI
+	}
+
+	lastUpdate := raw.LastUpdate
+	if lastUpdate == nil {
+		lastUpdate = raw.LastUpdateV1
+	}
+
+	patch, patches, err := decodePatch(raw.Patch)
+	if err != nil {
+		return err
+	}
+	if raw.Patches != nil {
+		patches = stringSlice(raw.Patches)
+	}
+
+	*a = Addon{
+		Id:            raw.Id.ptr(),
+		Name:          raw.Name.ptr(),
+		SmallDesc:     raw.SmallDesc.ptr(),
+		Author:        raw.Author.ptr(),
+		Version:       raw.Version.ptr(),
+		ScreenshotUrl: raw.ScreenshotUrl.ptr(),
+		URL:           raw.URL.ptr(),
+		Category:      raw.Category.ptr(),
+		Downloads:     raw.Downloads.ptr(),
+		LastUpdate:    lastUpdate.ptr(),
+		Patch:         patch.ptr(),
+		WebUrl:        raw.WebUrl.ptr(),
+		LastDownload:  lastDownload.ptr(),
+		DonateUrl:     raw.DonateUrl.ptr(),
+		Slug:          raw.Slug.ptr(),
+		ChangelogUrl:  raw.ChangelogUrl.ptr(),
+		TicketUrl:     raw.TicketUrl.ptr(),
+		GitUrl:        raw.GitUrl.ptr(),
+		LogoUrl:       raw.LogoUrl.ptr(),
+		Directories:   stringSlice(raw.Directories),
+		Patches:       patches,
+		Extra:         extra,
+	}
+
+	return nil
+}
+
+// decodePatch decodes the patch of api.php or the list of patches of the v1 API.
+func decodePatch(data json.RawMessage) (*Scalar, []string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil, nil
+	}
+
+	if data[0] == '[' {
+		var patches []Scalar
+		if err := json.Unmarshal(data, &patches); err != nil {
+			return nil, nil, err
+		}
+		return nil, stringSlice(patches), nil
+	}
+
+	var patch *Scalar
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, nil, err
+	}
+
+	return patch, nil, nil
+}
+
+func stringSlice(scalars []Scalar) []string {
+	if scalars == nil {
+		return nil
+	}
+
+	s := make([]string, len(scalars))
+	for i, scalar := range scalars {
+		s[i] = string(scalar)
+	}
+
+	return s
+}
+
+// MarshalJSON encodes the addon including its Extra fields. The known fields take
+// precedence over Extra fields of the same name.
+func (a Addon) MarshalJSON() ([]byte, error) {
+	// addon has the fields of Addon but not its methods
+	type addon Addon
+
+	data, err := json.Marshal(addon(a))
+	if err != nil || len(a.Extra) == 0 {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	for key, value := range a.Extra {
+		if _, ok := fields[key]; !ok {
+			fields[key] = value
+		}
+	}
+
+	return json.Marshal(fields)
+}
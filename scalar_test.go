@@ -0,0 +1,203 @@
+package tukui
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScalar_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  *string
+		err   bool
+	}{
+		{`"3.53"`, String("3.53"), false},
+		{`""`, String(""), false},
+		{`"a \"quoted\" é"`, String(`a "quoted" é`), false},
+		{`-2`, String("-2"), false},
+		{`1.10`, String("1.10"), false},
+		{`1e3`, String("1e3"), false},
+		{`true`, String("true"), false},
+		{`false`, String("false"), false},
+		{`null`, nil, false},
+		{`{"major": 1}`, nil, true},
+		{`[1, 2]`, nil, true},
+	}
+
+	for _, tt := range tests {
+		var got struct {
+			Value *Scalar `json:"value"`
+		}
+
+		err := json.Unmarshal([]byte(`{"value": `+tt.input+`}`), &got)
+		if (err != nil) != tt.err {
+			t.Errorf("Unmarshal(%s) returned error %v, want error %v", tt.input, err, tt.err)
+			continue
+		}
+
+		if !tt.err && !cmp.Equal(got.Value.ptr(), tt.want) {
+			t.Errorf("Unmarshal(%s) returned %v, want %v", tt.input, got.Value.ptr(), tt.want)
+		}
+	}
+}
+
+func TestAddon_UnmarshalJSON(t *testing.T) {
+	input := `{
+		"id": -2,
+		"name": "ElvUI",
+		"version": 13.64,
+		"downloads": 1234567,
+		"patch": 9.02,
+		"lastdownload": "2020-09-21 13:58:12",
+		"category": true,
+		"author": null,
+		"changelog": "https://www.tukui.org/ui/elvui/changelog"
+	}`
+
+	var got Addon
+	if err := json.Unmarshal([]byte(input), &got); err != nil {
+		t.Fatalf("Unmarshal() returned error: %v", err)
+	}
+
+	want := Addon{
+		Id:           String("-2"),
+		Name:         String("ElvUI"),
+		Version:      String("13.64"),
+		Downloads:    String("1234567"),
+		Patch:        String("9.02"),
+		LastDownload: String("2020-09-21 13:58:12"),
+		Category:     String("true"),
+		Extra: map[string]json.RawMessage{
+			"changelog": json.RawMessage(`"https://www.tukui.org/ui/elvui/changelog"`),
+		},
+	}
+
+	if !cmp.Equal(got, want) {
+		t.Errorf("Unmarshal() returned %+v, want %+v", got, want)
+	}
+}
+
+func TestAddon_UnmarshalJSON_LastDownload(t *testing.T) {
+	var got Addon
+	if err := json.Unmarshal([]byte(`{"last_download": "2020-09-21", "lastdownload": "2019-01-01"}`), &got); err != nil {
+		t.Fatalf("Unmarshal() returned error: %v", err)
+	}
+
+	want := Addon{LastDownload: String("2020-09-21")}
+	if !cmp.Equal(got, want) {
+		t.Errorf("Unmarshal() returned %+v, want %+v", got, want)
+	}
+}
+
+func TestAddon_UnmarshalJSON_Extra(t *testing.T) {
+	input := `{"ID": "3", "changelog_url": "https://www.tukui.org/addons/3/changelog", "gallery_url": "https://www.tukui.org/addons/3/gallery", "tags": ["skins"]}`
+
+	var got Addon
+	if err := json.Unmarshal([]byte(input), &got); err != nil {
+		t.Fatalf("Unmarshal() returned error: %v", err)
+	}
+
+	want := Addon{
+		Id:           String("3"),
+		ChangelogUrl: String("https://www.tukui.org/addons/3/changelog"),
+		Extra: map[string]json.RawMessage{
+			"gallery_url": json.RawMessage(`"https://www.tukui.org/addons/3/gallery"`),
+			"tags":        json.RawMessage(`["skins"]`),
+		},
+	}
+
+	if !cmp.Equal(got, want) {
+		t.Errorf("Unmarshal() returned %+v, want %+v", got, want)
+	}
+}
+
+func TestAddon_UnmarshalJSON_RoundTrip(t *testing.T) {
+	addon := Addon{
+		Id:           String("3"),
+		Name:         String("AddOnSkins"),
+		LastDownload: String("2020-09-21"),
+		Directories:  []string{"AddOnSkins", "AddOnSkins_Plugins"},
+		Patches:      []string{"10.1.5", "3.4.2"},
+		Extra: map[string]json.RawMessage{
+			"gallery_url": json.RawMessage(`"https://www.tukui.org/addons/3/gallery"`),
+		},
+	}
+
+	data, err := json.Marshal(addon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got Addon
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() returned error: %v", err)
+	}
+
+	if !cmp.Equal(got, addon) {
+		t.Errorf("Unmarshal(Marshal()) returned %+v, want %+v", got, addon)
+	}
+}
+
+func TestAddon_MarshalJSON_KnownFieldsWin(t *testing.T) {
+	addon := Addon{
+		Id:    String("3"),
+		Extra: map[string]json.RawMessage{"id": json.RawMessage(`"4"`), "git_url": json.RawMessage(`"https://github.com/Azilroka/AddOnSkins"`)},
+	}
+
+	data, err := json.Marshal(addon)
+	if err != nil {
+		t.Fatalf("Marshal() returned error: %v", err)
+	}
+
+	want := `{"git_url":"https://github.com/Azilroka/AddOnSkins","id":"3"}`
+	if string(data) != want {
+		t.Errorf("Marshal() returned %s, want %s", data, want)
+	}
+}
+
+func TestAddon_UnmarshalJSON_V1(t *testing.T) {
+	input := `{
+		"id": -2,
+		"slug": "elvui",
+		"author": "Elv",
+		"name": "ElvUI",
+		"url": "https://api.tukui.org/v1/download/dev/elvui/main",
+		"version": "13.40",
+		"changelog_url": "https://api.tukui.org/v1/changelog/elvui#13.40",
+		"ticket_url": "https://github.com/tukui-org/ElvUI/issues",
+		"git_url": "https://github.com/tukui-org/ElvUI",
+		"patch": ["10.1.5", "1.14.4", "3.4.2"],
+		"last_update": "2023-08-09",
+		"web_url": "https://tukui.org/elvui",
+		"logo_url": "https://tukui.org/logo/elvui.png",
+		"directories": ["ElvUI", "ElvUI_Options", "ElvUI_Libraries"]
+	}`
+
+	var got Addon
+	if err := json.Unmarshal([]byte(input), &got); err != nil {
+		t.Fatalf("Unmarshal() returned error: %v", err)
+	}
+
+	want := Addon{
+		Id:           String("-2"),
+		Slug:         String("elvui"),
+		Author:       String("Elv"),
+		Name:         String("ElvUI"),
+		URL:          String("https://api.tukui.org/v1/download/dev/elvui/main"),
+		Version:      String("13.40"),
+		ChangelogUrl: String("https://api.tukui.org/v1/changelog/elvui#13.40"),
+		TicketUrl:    String("https://github.com/tukui-org/ElvUI/issues"),
+		GitUrl:       String("https://github.com/tukui-org/ElvUI"),
+		Patches:      []string{"10.1.5", "1.14.4", "3.4.2"},
+		LastUpdate:   String("2023-08-09"),
+		WebUrl:       String("https://tukui.org/elvui"),
+		LogoUrl:      String("https://tukui.org/logo/elvui.png"),
+		Directories:  []string{"ElvUI", "ElvUI_Options", "ElvUI_Libraries"},
+	}
+
+	if !cmp.Equal(got, want) {
+		t.Errorf("Unmarshal() returned %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,348 @@
+package tukui
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Confidence describes how an installed addon was matched to a catalog Addon.
+type Confidence int
+
+const (
+	// NoMatch means the installed addon was not found in the catalog
+	NoMatch Confidence = iota
+	// MatchFuzzy means the normalized folder name or title resembles the catalog name
+	MatchFuzzy
+	// MatchTitle means the TOC title equals the catalog name
+	MatchTitle
+	// MatchProjectID means the X-Tukui-ProjectID of the TOC equals the catalog ID
+	MatchProjectID
+)
+
+func (c Confidence) String() string {
+	switch c {
+	case MatchFuzzy:
+		return "fuzzy"
+	case MatchTitle:
+		return "title"
+	case MatchProjectID:
+		return "project-id"
+	}
+
+	return "none"
+}
+
+// An InstalledFolder is a folder of an AddOns directory with a loadable .toc file.
+type InstalledFolder struct {
+	// Name of the folder
+	Name string
+	// TOC of the folder chosen for the scanned flavor
+	TOC *TOC
+}
+
+// An InstalledAddon is a group of installed folders that belong to one addon, e.g.
+// ElvUI, ElvUI_Options and ElvUI_Libraries.
+type InstalledAddon struct {
+	// Folders of the addon, the main folder first
+	Folders []InstalledFolder
+	// Addon is the matched catalog entry, nil if it could not be matched
+	Addon *Addon
+	// Confidence of the match
+	Confidence Confidence
+}
+
+// Name returns the name of the main folder.
+func (a InstalledAddon) Name() string {
+	return a.Folders[0].Name
+}
+
+// Version returns the version of the main folder's TOC.
+func (a InstalledAddon) Version() string {
+	return a.Folders[0].TOC.Version
+}
+
+// ScanResult is returned by Scan.
+type ScanResult struct {
+	// Addons found in the AddOns directory
+	Addons []InstalledAddon
+	// Unmatched lists the folders of addons not found in the catalog
+	Unmatched []string
+	// Skipped lists the folders whose .toc file could not be read
+	Skipped []FolderError
+}
+
+// A FolderError is a folder of an AddOns directory skipped by a scan.
+type FolderError struct {
+	// Folder is the name of the skipped folder
+	Folder string
+	// Err is the reason the folder was skipped
+	Err error
+}
+
+func (e FolderError) Error() string {
+	return e.Folder + ": " + e.Err.Error()
+}
+
+func (e FolderError) Unwrap() error {
+	return e.Err
+}
+
+// ScanAddOns reads the .toc files of all folders in addonsDir and groups the folders
+// that belong to one addon. For folders with .toc files for several flavors, the
+// file for the given flavor is used. Folders without a loadable .toc file are skipped,
+// use Scan to learn about folders with broken .toc files.
+//
+// Folders are grouped by name. A folder like ElvUI_Options belongs to ElvUI if it is
+// named after it and has the same project ID or version. Plugins like ElvUI_SLE, which
+// are released on their own, form a separate group.
+func ScanAddOns(addonsDir string, flavor Flavor) ([]InstalledAddon, error) {
+	addons, _, err := scanAddOns(addonsDir, flavor)
+
+	return addons, err
+}
+
+// scanAddOns is ScanAddOns returning the folders skipped because of an error. A
+// single broken addon must not break the scan of the whole directory.
+func scanAddOns(addonsDir string, flavor Flavor) ([]InstalledAddon, []FolderError, error) {
+	entries, err := ioutil.ReadDir(addonsDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var folders []InstalledFolder
+	var skipped []FolderError
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		toc, err := readFolderTOC(filepath.Join(addonsDir, entry.Name()), flavor)
+		if err != nil {
+			skipped = append(skipped, FolderError{Folder: entry.Name(), Err: err})
+			continue
+		}
+
+		if toc != nil {
+			folders = append(folders, InstalledFolder{Name: entry.Name(), TOC: toc})
+		}
+	}
+
+	return groupFolders(folders), skipped, nil
+}
+
+// readFolderTOC reads the .toc file the game client of the given flavor loads for
+// the folder. It returns nil if the folder has no .toc file named after it.
+func readFolderTOC(dir string, flavor Flavor) (*TOC, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	folder := filepath.Base(dir)
+	best, bestRank := "", 0
+
+	for _, file := range files {
+		if file.IsDir() || !strings.EqualFold(filepath.Ext(file.Name()), ".toc") {
+			continue
+		}
+
+		name, tocFlavor := ParseTOCName(file.Name())
+		if !strings.EqualFold(name, folder) {
+			continue
+		}
+
+		if rank := tocRank(tocFlavor, flavor, file.Name()); rank > bestRank {
+			best, bestRank = file.Name(), rank
+		}
+	}
+
+	if best == "" {
+		return nil, nil
+	}
+
+	return ReadTOC(filepath.Join(dir, best))
+}
+
+// tocRank orders the .toc files of a folder like the game client does: a file
+// for the exact flavor wins over the generic _Classic file, which wins over a
+// file without suffix. Files for other flavors are not loaded at all.
+func tocRank(tocFlavor, flavor Flavor, filename string) int {
+	switch {
+	case tocFlavor == "":
+		return 1
+	case tocFlavor == flavor && !strings.HasSuffix(strings.ToLower(filename), "classic.toc"):
+		return 3
+	case tocFlavor == FlavorClassic && flavor.IsClassic():
+		return 2
+	}
+
+	return 0
+}
+
+func groupFolders(folders []InstalledFolder) []InstalledAddon {
+	sort.Slice(folders, func(i, j int) bool {
+		return len(folders[i].Name) < len(folders[j].Name) ||
+			len(folders[i].Name) == len(folders[j].Name) && folders[i].Name < folders[j].Name
+	})
+
+	var addons []InstalledAddon
+
+	for _, folder := range folders {
+		group := -1
+		for i := range addons {
+			if belongsTo(folder, addons[i].Folders[0]) {
+				group = i
+				break
+			}
+		}
+
+		if group < 0 {
+			addons = append(addons, InstalledAddon{})
+			group = len(addons) - 1
+		}
+
+		addons[group].Folders = append(addons[group].Folders, folder)
+	}
+
+	sort.Slice(addons, func(i, j int) bool {
+		return strings.ToLower(addons[i].Name()) < strings.ToLower(addons[j].Name())
+	})
+
+	return addons
+}
+
+// belongsTo reports whether folder is a part of the addon with the given main folder.
+func belongsTo(folder, main InstalledFolder) bool {
+	if !hasFolderPrefix(folder.Name, main.Name) {
+		return false
+	}
+
+	if id, ok := folder.TOC.TukuiProjectID(); ok {
+		mainID, _ := main.TOC.TukuiProjectID()
+		return id == mainID
+	}
+
+	if folder.TOC.Version != "" || main.TOC.Version != "" {
+		return folder.TOC.Version == main.TOC.Version
+	}
+
+	for _, dep := range folder.TOC.Dependencies {
+		if strings.EqualFold(dep, main.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasFolderPrefix reports whether name is prefixed by the name of the main folder,
+// like ElvUI_Options for ElvUI.
+func hasFolderPrefix(name, main string) bool {
+	return len(name) > len(main) && strings.EqualFold(name[:len(main)], main) && strings.ContainsRune("_-", rune(name[len(main)]))
+}
+
+// MatchAddons assigns each installed addon the best matching Addon of the catalog.
+// The X-Tukui-ProjectID field of the .toc files is the most reliable source, followed
+// by the exact title and finally a fuzzy comparison of names.
+func MatchAddons(installed []InstalledAddon, catalog []Addon) ScanResult {
+	var result ScanResult
+
+	for _, addon := range installed {
+		addon.Addon, addon.Confidence = matchAddon(addon, catalog)
+		if addon.Confidence == NoMatch {
+			for _, folder := range addon.Folders {
+				result.Unmatched = append(result.Unmatched, folder.Name)
+			}
+		}
+
+		result.Addons = append(result.Addons, addon)
+	}
+
+	return result
+}
+
+func matchAddon(installed InstalledAddon, catalog []Addon) (*Addon, Confidence) {
+	var best *Addon
+	confidence := NoMatch
+
+	for i := range catalog {
+		c := matchConfidence(installed, catalog[i])
+		if c > confidence {
+			best, confidence = &catalog[i], c
+		}
+	}
+
+	if best != nil {
+		addon := *best
+		best = &addon
+	}
+
+	return best, confidence
+}
+
+func matchConfidence(installed InstalledAddon, addon Addon) Confidence {
+	main := installed.Folders[0]
+
+	if addon.Id != nil {
+		for _, folder := range installed.Folders {
+			if id, ok := folder.TOC.TukuiProjectID(); ok && id == *addon.Id {
+				return MatchProjectID
+			}
+		}
+	}
+
+	if addon.Name == nil {
+		return NoMatch
+	}
+
+	if main.TOC.Title != "" && strings.EqualFold(main.TOC.Title, strings.TrimSpace(*addon.Name)) {
+		return MatchTitle
+	}
+
+	name := normalizeName(*addon.Name)
+	if name == "" {
+		return NoMatch
+	}
+
+	for _, candidate := range []string{main.Name, main.TOC.Title} {
+		if normalizeName(candidate) == name {
+			return MatchFuzzy
+		}
+	}
+
+	return NoMatch
+}
+
+// normalizeName lower cases s and removes everything but letters and digits.
+func normalizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// Scan reads the AddOns directory for the given flavor and matches the installed
+// addons to the catalog of the AddonClient, including the UI suites. Folders with a
+// .toc file that cannot be read are reported in ScanResult.Skipped.
+func Scan(addonsDir string, flavor Flavor, client AddonClient) (ScanResult, error) {
+	installed, skipped, err := scanAddOns(addonsDir, flavor)
+	if err != nil {
+		return ScanResult{}, err
+	}
+
+	catalog, err := FetchCatalog(context.Background(), client)
+	if err != nil {
+		return ScanResult{}, err
+	}
+
+	result := MatchAddons(installed, catalog)
+	result.Skipped = skipped
+
+	return result, nil
+}
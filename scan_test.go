@@ -0,0 +1,232 @@
+package tukui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeAddOns creates the given .toc files, keyed by their slash separated path, in dir
+func writeAddOns(t *testing.T, dir string, tocs map[string]string) {
+	t.Helper()
+	for name, content := range tocs {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func folderNames(addon InstalledAddon) []string {
+	var names []string
+	for _, folder := range addon.Folders {
+		names = append(names, folder.Name)
+	}
+
+	return names
+}
+
+func TestScanAddOns(t *testing.T) {
+	dir := tempDir(t)
+	writeAddOns(t, dir, map[string]string{
+		"ElvUI/ElvUI_Mainline.toc":                  "## Title: ElvUI\n## Version: v13.64\n## RequiredDeps: ElvUI_Libraries\n",
+		"ElvUI/ElvUI_Vanilla.toc":                   "## Title: ElvUI\n## Version: v1.05\n",
+		"ElvUI_Options/ElvUI_Options_Mainline.toc":  "## Title: ElvUI Options\n## Version: v13.64\n## RequiredDeps: ElvUI\n",
+		"ElvUI_Libraries/ElvUI_Libraries.toc":       "## Title: ElvUI Libraries\n## Version: v13.64\n",
+		"ElvUI_SLE/ElvUI_SLE.toc":                   "## Title: Shadow & Light\n## Version: 5.01\n## RequiredDeps: ElvUI\n",
+		"AddOnSkins/AddOnSkins.toc":                 "## Title: |cff16C3F2AddOn|rSkins\n## Version: 4.41\n",
+		"Details/Details.toc":                       "## Title: Details! Damage Meter\n",
+		"Details_Streamer/Details_Streamer.toc":     "## Title: Details! Streamer\n## RequiredDeps: Details\n",
+		"NoTOC/readme.txt":                          "",
+		"Blizzard_Fake/Other.toc":                   "## Title: Other\n",
+		".tukui-staging-1/ElvUI/ElvUI_Mainline.toc": "## Title: ElvUI\n",
+	})
+
+	addons, err := ScanAddOns(dir, FlavorRetail)
+	if err != nil {
+		t.Fatalf("ScanAddOns() returned error: %v", err)
+	}
+
+	var got [][]string
+	for _, addon := range addons {
+		got = append(got, folderNames(addon))
+	}
+
+	want := [][]string{
+		{"AddOnSkins"},
+		{"Details", "Details_Streamer"},
+		{"ElvUI", "ElvUI_Options", "ElvUI_Libraries"},
+		{"ElvUI_SLE"},
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("ScanAddOns() returned groups %v, want %v", got, want)
+	}
+
+	if v := addons[2].Version(); v != "v13.64" {
+		t.Errorf("ScanAddOns() read version %q for ElvUI, want %q", v, "v13.64")
+	}
+}
+
+func TestScanAddOns_ClassicTOC(t *testing.T) {
+	dir := tempDir(t)
+	writeAddOns(t, dir, map[string]string{
+		"ElvUI/ElvUI.toc":         "## Version: retail\n",
+		"ElvUI/ElvUI_Classic.toc": "## Version: classic\n",
+		"ElvUI/ElvUI_Wrath.toc":   "## Version: wrath\n",
+	})
+
+	tests := map[Flavor]string{
+		FlavorRetail:         "retail",
+		FlavorClassic:        "classic",
+		FlavorBurningCrusade: "classic",
+		FlavorWrath:          "wrath",
+	}
+
+	for flavor, want := range tests {
+		addons, err := ScanAddOns(dir, flavor)
+		if err != nil {
+			t.Fatalf("ScanAddOns() returned error: %v", err)
+		}
+
+		if got := addons[0].Version(); got != want {
+			t.Errorf("ScanAddOns() for %s read version %q, want %q", flavor, got, want)
+		}
+	}
+}
+
+func TestMatchAddons(t *testing.T) {
+	dir := tempDir(t)
+	writeAddOns(t, dir, map[string]string{
+		"AddOnSkins/AddOnSkins.toc":       "## Title: |cff16C3F2AddOn|rSkins\n",
+		"ElvUI_LocPlus/ElvUI_LocPlus.toc": "## Title: Location Plus\n## X-Tukui-ProjectID: 6\n",
+		"ElvUI/ElvUI.toc":                 "## Title: |cff1784d1ElvUI|r\n",
+		"Tukui/Tukui.toc":                 "## Title: Tukui UI\n",
+		"Details/Details.toc":             "## Title: Details! Damage Meter\n",
+	})
+
+	installed, err := ScanAddOns(dir, FlavorRetail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	catalog := []Addon{
+		{Id: String("3"), Name: String("AddOnSkins")},
+		{Id: String("6"), Name: String("LocationPlus for ElvUI")},
+		{Id: String("-2"), Name: String("ElvUI")},
+		{Id: String("-1"), Name: String("TukUI")},
+	}
+
+	result := MatchAddons(installed, catalog)
+
+	type match struct {
+		Folder     string
+		ID         string
+		Confidence Confidence
+	}
+
+	var got []match
+	for _, addon := range result.Addons {
+		m := match{Folder: addon.Name(), Confidence: addon.Confidence}
+		if addon.Addon != nil {
+			m.ID = *addon.Addon.Id
+		}
+		got = append(got, m)
+	}
+
+	want := []match{
+		{"AddOnSkins", "3", MatchTitle},
+		{"Details", "", NoMatch},
+		{"ElvUI", "-2", MatchTitle},
+		{"ElvUI_LocPlus", "6", MatchProjectID},
+		{"Tukui", "-1", MatchFuzzy},
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("MatchAddons() returned %+v, want %+v", got, want)
+	}
+
+	if !cmp.Equal(result.Unmatched, []string{"Details"}) {
+		t.Errorf("MatchAddons() returned unmatched %v, want %v", result.Unmatched, []string{"Details"})
+	}
+}
+
+func TestScan(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case query.Get("addons") == "all":
+			fmt.Fprint(w, `[{"id": "3", "name": "AddOnSkins", "version": "4.41"}]`)
+		case query.Get("ui") == "tukui":
+			fmt.Fprint(w, `{"id": -1, "name": "Tukui", "version": "20.38"}`)
+		case query.Get("ui") == "elvui":
+			fmt.Fprint(w, `{"id": -2, "name": "ElvUI", "version": "13.64"}`)
+		default:
+			t.Errorf("unexpected query %v", query)
+		}
+	})
+
+	dir := tempDir(t)
+	writeAddOns(t, dir, map[string]string{
+		"AddOnSkins/AddOnSkins.toc": "## Title: AddOnSkins\n",
+		"ElvUI/ElvUI.toc":           "## Title: ElvUI\n## Version: v13.60\n",
+	})
+
+	result, err := Scan(dir, FlavorRetail, client.RetailAddons)
+	if err != nil {
+		t.Fatalf("Scan() returned error: %v", err)
+	}
+
+	if len(result.Addons) != 2 || len(result.Unmatched) != 0 {
+		t.Fatalf("Scan() returned %+v", result)
+	}
+
+	if elvui := result.Addons[1].Addon; elvui == nil || *elvui.Version != "13.64" {
+		t.Errorf("Scan() matched ElvUI to %+v", elvui)
+	}
+}
+
+func TestScan_BrokenTOC(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("addons") == "all" {
+			fmt.Fprint(w, `[{"id": "3", "name": "AddOnSkins", "version": "4.41"}]`)
+		}
+	})
+
+	dir := tempDir(t)
+	writeAddOns(t, dir, map[string]string{
+		"AddOnSkins/AddOnSkins.toc": "## Title: AddOnSkins\n",
+		"Broken/Broken.toc":         "## Title: Broken\n## Interface: eleven\n",
+	})
+
+	addons, err := ScanAddOns(dir, FlavorRetail)
+	if err != nil || len(addons) != 1 {
+		t.Errorf("ScanAddOns() returned %d addons, %v, want 1 addon", len(addons), err)
+	}
+
+	result, err := Scan(dir, FlavorRetail, client.RetailAddons)
+	if err != nil {
+		t.Fatalf("Scan() returned error: %v", err)
+	}
+
+	if len(result.Addons) != 1 || result.Addons[0].Addon == nil {
+		t.Errorf("Scan() returned addons %+v, want AddOnSkins", result.Addons)
+	}
+
+	if len(result.Skipped) != 1 || result.Skipped[0].Folder != "Broken" || result.Skipped[0].Err == nil {
+		t.Errorf("Scan() returned skipped %+v, want Broken", result.Skipped)
+	}
+}
@@ -0,0 +1,87 @@
+package tukui
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ErrSlugCollision is returned if several addons of a catalog match the same slug.
+var ErrSlugCollision = errors.New("slug collision")
+
+// FindBySlug returns the addon of the catalog with the given slug, e.g. elvui or
+// addonskins. An addon matches by its Slug, or by a slug derived from the last
+// segment of its WebUrl or from its Name. Derived slugs are compared ignoring case
+// and anything but letters and digits, so "location-plus" matches "Location Plus".
+//
+// It returns ErrSlugCollision if several addons match and ErrEmptyResponse if none does.
+func FindBySlug(addons []Addon, slug string) (Addon, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+
+	var exact []int
+	for i, addon := range addons {
+		if addon.Slug != nil && strings.EqualFold(*addon.Slug, slug) {
+			exact = append(exact, i)
+		}
+	}
+
+	if len(exact) > 0 {
+		return pickSlug(addons, exact, slug)
+	}
+
+	want := normalizeName(slug)
+	if want == "" {
+		return Addon{}, fmt.Errorf("%w: invalid slug %q", ErrEmptyResponse, slug)
+	}
+
+	var derived []int
+	for i, addon := range addons {
+		for _, s := range derivedSlugs(addon) {
+			if s == want {
+				derived = append(derived, i)
+				break
+			}
+		}
+	}
+
+	return pickSlug(addons, derived, slug)
+}
+
+func pickSlug(addons []Addon, matches []int, slug string) (Addon, error) {
+	switch len(matches) {
+	case 0:
+		return Addon{}, fmt.Errorf("%w: no addon with slug %q", ErrEmptyResponse, slug)
+	case 1:
+		return addons[matches[0]], nil
+	}
+
+	names := make([]string, len(matches))
+	for i, n := range matches {
+		names[i] = fmt.Sprintf("%s (id %s)", stringValue(addons[n].Name), stringValue(addons[n].Id))
+	}
+
+	return Addon{}, fmt.Errorf("%w: %q matches %s", ErrSlugCollision, slug, strings.Join(names, ", "))
+}
+
+// derivedSlugs returns the normalized slugs of an addon without a Slug. The web
+// pages of api.php addons are addressed by ID, e.g. addons.php?id=3, and yield none.
+func derivedSlugs(addon Addon) []string {
+	var slugs []string
+
+	if addon.WebUrl != nil {
+		if u, err := url.Parse(*addon.WebUrl); err == nil && u.RawQuery == "" {
+			segment := path.Base(strings.TrimSuffix(u.Path, "/"))
+			if segment != "." && segment != "/" && path.Ext(segment) == "" {
+				slugs = append(slugs, normalizeName(segment))
+			}
+		}
+	}
+
+	if addon.Name != nil {
+		slugs = append(slugs, normalizeName(*addon.Name))
+	}
+
+	return slugs
+}
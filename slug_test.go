@@ -0,0 +1,61 @@
+package tukui
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFindBySlug(t *testing.T) {
+	addons := []Addon{
+		{Id: String("3"), Name: String("AddOnSkins"), WebUrl: String("https://www.tukui.org/addons.php?id=3")},
+		{Id: String("6"), Name: String("Location Plus"), WebUrl: String("https://www.tukui.org/addons.php?id=6")},
+		{Id: String("-2"), Name: String("ElvUI"), Slug: String("elvui")},
+		{Id: String("12"), Name: String("Shadow & Light"), WebUrl: String("https://tukui.org/elvui-sle")},
+		{Id: String("40"), Name: String("ElvUI"), WebUrl: String("https://www.tukui.org/addons.php?id=40")},
+	}
+
+	tests := []struct {
+		slug string
+		id   string
+		err  error
+	}{
+		{"addonskins", "3", nil},
+		{" AddOnSkins ", "3", nil},
+		{"location-plus", "6", nil},
+		{"locationplus", "6", nil},
+		{"elvui", "-2", nil},
+		{"elvui-sle", "12", nil},
+		{"shadow-light", "12", nil},
+		{"weakauras", "", ErrEmptyResponse},
+		{"---", "", ErrEmptyResponse},
+	}
+
+	for _, tt := range tests {
+		addon, err := FindBySlug(addons, tt.slug)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("FindBySlug(%q) returned error %v, want %v", tt.slug, err, tt.err)
+			continue
+		}
+
+		if got := stringValue(addon.Id); got != tt.id {
+			t.Errorf("FindBySlug(%q) returned id %q, want %q", tt.slug, got, tt.id)
+		}
+	}
+}
+
+func TestFindBySlug_Collision(t *testing.T) {
+	addons := []Addon{
+		{Id: String("1"), Name: String("Tukui")},
+		{Id: String("-1"), Name: String("TukUI")},
+	}
+
+	_, err := FindBySlug(addons, "tukui")
+	if !errors.Is(err, ErrSlugCollision) {
+		t.Fatalf("FindBySlug() returned error %v, want %v", err, ErrSlugCollision)
+	}
+
+	want := `slug collision: "tukui" matches Tukui (id 1), TukUI (id -1)`
+	if err.Error() != want {
+		t.Errorf("FindBySlug() returned error %q, want %q", err, want)
+	}
+}
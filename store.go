@@ -0,0 +1,413 @@
+package tukui
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotStored is returned if the Store has no .zip file for an addon version.
+var ErrNotStored = errors.New("addon version not in store")
+
+// ErrChecksumMismatch is returned if the content of a stored .zip file does not match its SHA-256 checksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrInvalidStoreKey is returned for addon IDs and versions a Store cannot use as
+// file names, which are the empty string, "." and "..".
+var ErrInvalidStoreKey = errors.New("invalid store key")
+
+// A Store keeps downloaded addon .zip files on the local disk. Files are addressed by
+// their SHA-256 checksum, so identical payloads are only stored once, and referenced
+// by the addon ID and version they were downloaded for.
+//
+// A Store is safe for concurrent use within one process.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// StoreEntry describes an addon version held by a Store.
+type StoreEntry struct {
+	// ID of the addon
+	ID string `json:"id"`
+	// version of the addon
+	Version string `json:"version"`
+	// hex encoded SHA-256 checksum of the .zip file
+	SHA256 string `json:"sha256"`
+	// size of the .zip file in bytes
+	Size int64 `json:"size"`
+	// when the version was added to the store
+	Added time.Time `json:"added"`
+}
+
+// RetentionPolicy defines which entries Store.GC keeps. The newest version of every
+// addon is always kept.
+type RetentionPolicy struct {
+	// KeepVersions is the number of versions kept per addon. Zero keeps all versions.
+	KeepVersions int
+	// MaxAge is the duration after which older versions are removed. Zero disables the limit.
+	MaxAge time.Duration
+}
+
+// NewStore opens the Store in dir and creates the directory if needed.
+func NewStore(dir string) (*Store, error) {
+	for _, sub := range []string{"blobs", "refs", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Store{dir: dir}, nil
+}
+
+// Lookup returns the entry for the given addon ID and version.
+func (s *Store) Lookup(id, version string) (StoreEntry, error) {
+	ref, err := s.refPath(id, version)
+	if err != nil {
+		return StoreEntry{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.readRef(ref)
+}
+
+// Open returns a reader for the .zip file of the given addon ID and version. The
+// content is verified against its checksum before it is returned. A corrupted entry
+// is removed from the store and ErrChecksumMismatch is returned.
+func (s *Store) Open(id, version string) (io.ReadCloser, StoreEntry, error) {
+	ref, err := s.refPath(id, version)
+	if err != nil {
+		return nil, StoreEntry{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.readRef(ref)
+	if err != nil {
+		return nil, StoreEntry{}, err
+	}
+
+	blob := s.blobPath(entry.SHA256)
+	h := sha256.New()
+	if err := hashFile(blob, h); err != nil {
+		if os.IsNotExist(err) {
+			os.Remove(ref)
+			return nil, StoreEntry{}, ErrNotStored
+		}
+		return nil, StoreEntry{}, err
+	}
+
+	if hexSum(h) != entry.SHA256 {
+		os.Remove(ref)
+		os.Remove(blob)
+		return nil, StoreEntry{}, fmt.Errorf("%s: %w", blob, ErrChecksumMismatch)
+	}
+
+	f, err := os.Open(blob)
+	if err != nil {
+		return nil, StoreEntry{}, err
+	}
+
+	return f, entry, nil
+}
+
+// Put adds the content of r as the .zip file of the given addon ID and version.
+func (s *Store) Put(id, version string, r io.Reader) (StoreEntry, error) {
+	w, err := s.newWriter(id, version)
+	if err != nil {
+		return StoreEntry{}, err
+	}
+
+	if _, err := io.Copy(w, r); err != nil {
+		w.abort()
+		return StoreEntry{}, err
+	}
+
+	return w.commit()
+}
+
+// Entries returns all entries of the Store sorted by ID and the time they were added.
+func (s *Store) Entries() ([]StoreEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.entries()
+}
+
+// GC removes the entries not covered by the RetentionPolicy and deletes all .zip
+// files no longer referenced by any entry. It returns the removed entries.
+func (s *Store) GC(policy RetentionPolicy) ([]StoreEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	kept := make(map[string]bool)
+	var removed []StoreEntry
+
+	for i := 0; i < len(entries); {
+		j := i
+		for j < len(entries) && entries[j].ID == entries[i].ID {
+			j++
+		}
+
+		// entries of one addon are sorted oldest first
+		versions := entries[i:j]
+		for k, entry := range versions {
+			newer := len(versions) - 1 - k
+			expired := policy.MaxAge > 0 && now.Sub(entry.Added) > policy.MaxAge
+			tooMany := policy.KeepVersions > 0 && newer >= policy.KeepVersions
+
+			if newer > 0 && (expired || tooMany) {
+				ref, err := s.refPath(entry.ID, entry.Version)
+				if err != nil {
+					return removed, err
+				}
+
+				if err := os.Remove(ref); err != nil && !os.IsNotExist(err) {
+					return removed, err
+				}
+				removed = append(removed, entry)
+				continue
+			}
+
+			kept[entry.SHA256] = true
+		}
+
+		i = j
+	}
+
+	blobs, err := ioutil.ReadDir(filepath.Join(s.dir, "blobs"))
+	if err != nil {
+		return removed, err
+	}
+
+	for _, blob := range blobs {
+		if !kept[strings.TrimSuffix(blob.Name(), ".zip")] {
+			if err := os.Remove(filepath.Join(s.dir, "blobs", blob.Name())); err != nil && !os.IsNotExist(err) {
+				return removed, err
+			}
+		}
+	}
+
+	return removed, nil
+}
+
+func (s *Store) entries() ([]StoreEntry, error) {
+	var entries []StoreEntry
+
+	err := filepath.Walk(filepath.Join(s.dir, "refs"), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		entry, err := s.readRef(path)
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, entry)
+
+		return nil
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].ID != entries[j].ID {
+			return entries[i].ID < entries[j].ID
+		}
+		return entries[i].Added.Before(entries[j].Added)
+	})
+
+	return entries, err
+}
+
+func (s *Store) readRef(path string) (StoreEntry, error) {
+	var entry StoreEntry
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return entry, ErrNotStored
+	}
+
+	if err != nil {
+		return entry, err
+	}
+
+	return entry, json.Unmarshal(data, &entry)
+}
+
+func (s *Store) refPath(id, version string) (string, error) {
+	for _, key := range []string{id, version} {
+		if !validStoreKey(key) {
+			return "", fmt.Errorf("%w %q", ErrInvalidStoreKey, key)
+		}
+	}
+
+	return filepath.Join(s.dir, "refs", url.PathEscape(id), url.PathEscape(version)+".json"), nil
+}
+
+// validStoreKey reports whether the addon ID or version can be part of a ref path.
+// url.PathEscape keeps "." and "..", which would leave the refs directory.
+func validStoreKey(key string) bool {
+	return key != "" && key != "." && key != ".."
+}
+
+func (s *Store) blobPath(sum string) string {
+	return filepath.Join(s.dir, "blobs", sum+".zip")
+}
+
+// storeWriter writes a new .zip file into a temporary file of the Store. It is only
+// added to the Store once commit is called.
+type storeWriter struct {
+	store   *Store
+	id      string
+	version string
+	file    *os.File
+	hash    hash.Hash
+	size    int64
+}
+
+func (s *Store) newWriter(id, version string) (*storeWriter, error) {
+	if _, err := s.refPath(id, version); err != nil {
+		return nil, err
+	}
+
+	f, err := ioutil.TempFile(filepath.Join(s.dir, "tmp"), "download-")
+	if err != nil {
+		return nil, err
+	}
+
+	return &storeWriter{
+		store:   s,
+		id:      id,
+		version: version,
+		file:    f,
+		hash:    sha256.New(),
+	}, nil
+}
+
+func (w *storeWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.hash.Write(p[:n])
+	w.size += int64(n)
+
+	return n, err
+}
+
+func (w *storeWriter) abort() {
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
+
+func (w *storeWriter) commit() (StoreEntry, error) {
+	defer os.Remove(w.file.Name())
+
+	if err := w.file.Sync(); err != nil {
+		w.file.Close()
+		return StoreEntry{}, err
+	}
+
+	if err := w.file.Close(); err != nil {
+		return StoreEntry{}, err
+	}
+
+	entry := StoreEntry{
+		ID:      w.id,
+		Version: w.version,
+		SHA256:  hexSum(w.hash),
+		Size:    w.size,
+		Added:   time.Now().UTC(),
+	}
+
+	s := w.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	blob := s.blobPath(entry.SHA256)
+	if _, err := os.Stat(blob); os.IsNotExist(err) {
+		if err := os.Rename(w.file.Name(), blob); err != nil {
+			return StoreEntry{}, err
+		}
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return StoreEntry{}, err
+	}
+
+	ref, err := s.refPath(entry.ID, entry.Version)
+	if err != nil {
+		return StoreEntry{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(ref), 0755); err != nil {
+		return StoreEntry{}, err
+	}
+
+	if err := writeFileAtomic(ref, data); err != nil {
+		return StoreEntry{}, err
+	}
+
+	return entry, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it,
+// so readers either see the old or the new content.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+"-")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,308 @@
+package tukui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := NewStore(tempDir(t))
+	if err != nil {
+		t.Fatalf("NewStore() returned error: %v", err)
+	}
+
+	return store
+}
+
+// backdate moves the time an entry was added into the past
+func backdate(t *testing.T, store *Store, id, version string, age time.Duration) {
+	t.Helper()
+	entry, err := store.Lookup(id, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry.Added = entry.Added.Add(-age)
+	if err := writeRef(store, entry); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeRef(store *Store, entry StoreEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	ref, err := store.refPath(entry.ID, entry.Version)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(ref, data)
+}
+
+func TestStore_PutOpen(t *testing.T) {
+	store := newTestStore(t)
+
+	entry, err := store.Put("3", "3.53", bytes.NewReader([]byte("zip")))
+	if err != nil {
+		t.Fatalf("Store.Put() returned error: %v", err)
+	}
+
+	if entry.ID != "3" || entry.Version != "3.53" || entry.Size != 3 || entry.SHA256 != sha256Hex([]byte("zip")) {
+		t.Errorf("Store.Put() returned %+v", entry)
+	}
+
+	r, got, err := store.Open("3", "3.53")
+	if err != nil {
+		t.Fatalf("Store.Open() returned error: %v", err)
+	}
+	defer r.Close()
+
+	data, _ := ioutil.ReadAll(r)
+	if string(data) != "zip" {
+		t.Errorf("Store.Open() content %q, want %q", data, "zip")
+	}
+
+	if !cmp.Equal(got, entry) {
+		t.Errorf("Store.Open() returned %+v, want %+v", got, entry)
+	}
+}
+
+func TestStore_Open_NotStored(t *testing.T) {
+	store := newTestStore(t)
+
+	_, _, err := store.Open("3", "3.53")
+	if !errors.Is(err, ErrNotStored) {
+		t.Errorf("Store.Open() returned %v, want %v", err, ErrNotStored)
+	}
+}
+
+func TestStore_Open_Corrupted(t *testing.T) {
+	store := newTestStore(t)
+
+	entry, err := store.Put("3", "3.53", bytes.NewReader([]byte("zip")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(store.blobPath(entry.SHA256), []byte("zap"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = store.Open("3", "3.53")
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Store.Open() returned %v, want %v", err, ErrChecksumMismatch)
+	}
+
+	if _, err := store.Lookup("3", "3.53"); !errors.Is(err, ErrNotStored) {
+		t.Errorf("Store.Lookup() of corrupted entry returned %v, want %v", err, ErrNotStored)
+	}
+}
+
+func TestStore_InvalidKey(t *testing.T) {
+	keys := []struct {
+		id, version string
+	}{
+		{"", "3.53"},
+		{".", "3.53"},
+		{"..", "3.53"},
+		{"3", ""},
+		{"3", "."},
+		{"3", ".."},
+	}
+
+	for _, key := range keys {
+		dir := tempDir(t)
+		store, err := NewStore(filepath.Join(dir, "store"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.Put(key.id, key.version, bytes.NewReader([]byte("zip"))); !errors.Is(err, ErrInvalidStoreKey) {
+			t.Errorf("Store.Put(%q, %q) returned %v, want %v", key.id, key.version, err, ErrInvalidStoreKey)
+		}
+
+		if _, err := store.Lookup(key.id, key.version); !errors.Is(err, ErrInvalidStoreKey) {
+			t.Errorf("Store.Lookup(%q, %q) returned %v, want %v", key.id, key.version, err, ErrInvalidStoreKey)
+		}
+
+		if _, _, err := store.Open(key.id, key.version); !errors.Is(err, ErrInvalidStoreKey) {
+			t.Errorf("Store.Open(%q, %q) returned %v, want %v", key.id, key.version, err, ErrInvalidStoreKey)
+		}
+
+		if got := listFiles(t, dir); len(got) != 0 {
+			t.Errorf("Store.Put(%q, %q) wrote %v", key.id, key.version, got)
+		}
+	}
+}
+
+func TestStore_Put_Deduplicates(t *testing.T) {
+	store := newTestStore(t)
+
+	for _, version := range []string{"1.0", "1.0a"} {
+		if _, err := store.Put("6", version, bytes.NewReader([]byte("same"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	blobs, err := ioutil.ReadDir(filepath.Join(store.dir, "blobs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(blobs) != 1 {
+		t.Errorf("Store holds %d blobs, want 1", len(blobs))
+	}
+
+	entries, err := store.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 {
+		t.Errorf("Store.Entries() returned %d entries, want 2", len(entries))
+	}
+}
+
+func TestStore_GC_KeepVersions(t *testing.T) {
+	store := newTestStore(t)
+
+	for i, version := range []string{"1.0", "1.1", "1.2"} {
+		if _, err := store.Put("6", version, bytes.NewReader([]byte(version))); err != nil {
+			t.Fatal(err)
+		}
+		backdate(t, store, "6", version, time.Duration(3-i)*time.Hour)
+	}
+
+	if _, err := store.Put("3", "3.53", bytes.NewReader([]byte("3.53"))); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := store.GC(RetentionPolicy{KeepVersions: 2})
+	if err != nil {
+		t.Fatalf("Store.GC() returned error: %v", err)
+	}
+
+	if len(removed) != 1 || removed[0].Version != "1.0" {
+		t.Errorf("Store.GC() removed %+v, want version 1.0", removed)
+	}
+
+	if _, err := os.Stat(store.blobPath(sha256Hex([]byte("1.0")))); !os.IsNotExist(err) {
+		t.Errorf("Store.GC() kept the blob of version 1.0")
+	}
+
+	for _, key := range [][2]string{{"6", "1.1"}, {"6", "1.2"}, {"3", "3.53"}} {
+		if _, err := store.Lookup(key[0], key[1]); err != nil {
+			t.Errorf("Store.Lookup(%q, %q) after GC returned error: %v", key[0], key[1], err)
+		}
+	}
+}
+
+func TestStore_GC_MaxAge(t *testing.T) {
+	store := newTestStore(t)
+
+	for _, version := range []string{"1.0", "1.1"} {
+		if _, err := store.Put("6", version, bytes.NewReader([]byte(version))); err != nil {
+			t.Fatal(err)
+		}
+		backdate(t, store, "6", version, 48*time.Hour)
+	}
+
+	removed, err := store.GC(RetentionPolicy{MaxAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Store.GC() returned error: %v", err)
+	}
+
+	if len(removed) != 1 || removed[0].Version != "1.0" {
+		t.Errorf("Store.GC() removed %+v, want version 1.0", removed)
+	}
+
+	if _, err := store.Lookup("6", "1.1"); err != nil {
+		t.Errorf("Store.GC() removed the newest version: %v", err)
+	}
+}
+
+func TestClient_Download_Store(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	var requests int
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/zip")
+		w.Write([]byte("zip content"))
+	})
+
+	store := newTestStore(t)
+	opts := &DownloadOptions{Store: store}
+	addon := Addon{Id: String("3"), Version: String("3.53"), URL: String(client.url + "download")}
+
+	for i := 0; i < 2; i++ {
+		var buf bytes.Buffer
+		result, resp, err := client.Download(context.Background(), addon, &buf, opts)
+		if err != nil {
+			t.Fatalf("Client.Download() returned error: %v", err)
+		}
+
+		want := DownloadResult{Size: 11, SHA256: sha256Hex([]byte("zip content")), Cached: i > 0}
+		if !cmp.Equal(result, want) {
+			t.Errorf("Client.Download() call %d returned %+v, want %+v", i, result, want)
+		}
+
+		if (resp == nil) != (i > 0) {
+			t.Errorf("Client.Download() call %d returned response %v", i, resp)
+		}
+
+		if buf.String() != "zip content" {
+			t.Errorf("Client.Download() call %d wrote %q", i, buf.String())
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("Client.Download() made %d requests, want 1", requests)
+	}
+}
+
+func TestClient_DownloadFile_Store(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	var requests int
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		serveZip(`"v1"`, resumePayload)(w, r)
+	})
+
+	dir := tempDir(t)
+	store := newTestStore(t)
+	opts := &DownloadOptions{Store: store}
+	addon := Addon{Id: String("3"), Version: String("3.53"), URL: String(client.url + "download")}
+
+	for _, name := range []string{"first.zip", "second.zip"} {
+		path := filepath.Join(dir, name)
+		result, _, err := client.DownloadFile(context.Background(), addon, path, opts)
+		if err != nil {
+			t.Fatalf("Client.DownloadFile() returned error: %v", err)
+		}
+
+		result.Cached = false
+		testDownloadedFile(t, path, result, resumePayload)
+	}
+
+	if requests != 1 {
+		t.Errorf("Client.DownloadFile() made %d requests, want 1", requests)
+	}
+}
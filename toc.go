@@ -0,0 +1,221 @@
+package tukui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A TOC holds the metadata of an addon read from its .toc file.
+type TOC struct {
+	// Name of the addon, the file name without flavor suffix and extension
+	Name string
+	// Flavor the file is meant for, empty if the file name has no flavor suffix
+	Flavor Flavor
+	// Interface numbers the addon supports, e.g. 100207 for patch 10.2.7
+	Interface []int
+	// Title with WoW escape sequences like color codes removed
+	Title string
+	// Notes with WoW escape sequences like color codes removed
+	Notes string
+	// Version of the addon
+	Version string
+	// Author of the addon
+	Author string
+	// Dependencies are the addons required to load this addon, read from the
+	// Dependencies, RequiredDeps and Dep* fields
+	Dependencies []string
+	// OptionalDeps are the addons loaded before this addon if they are available
+	OptionalDeps []string
+	// Fields holds all metadata fields as they appear in the file
+	Fields map[string]string
+	// Files lists the files loaded by the addon
+	Files []string
+}
+
+// tocSuffixes maps the flavor suffixes of .toc file names to the flavor they belong to.
+// A _Classic suffix is loaded by every Classic client, it is mapped to the Classic Era.
+var tocSuffixes = map[string]Flavor{
+	"mainline": FlavorRetail,
+	"classic":  FlavorClassic,
+	"vanilla":  FlavorClassic,
+	"tbc":      FlavorBurningCrusade,
+	"bcc":      FlavorBurningCrusade,
+	"wrath":    FlavorWrath,
+	"wotlkc":   FlavorWrath,
+	"cata":     FlavorCataclysm,
+}
+
+// escapeSequences matches the WoW UI escape sequences for colors, textures and atlases
+var escapeSequences = regexp.MustCompile(`\|c[0-9a-fA-F]{8}|\|r|\|T[^|]*\|t|\|A[^|]*\|a`)
+
+// ReadTOC parses the .toc file at path. Name and Flavor are derived from the file name.
+func ReadTOC(path string) (*TOC, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	toc, err := ParseTOC(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	toc.Name, toc.Flavor = ParseTOCName(filepath.Base(path))
+
+	return toc, nil
+}
+
+// ParseTOCName splits the file name of a .toc file like ElvUI_Mainline.toc or
+// ElvUI-Classic.toc into the addon name and the flavor of its suffix.
+func ParseTOCName(filename string) (string, Flavor) {
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+
+	i := strings.LastIndexAny(name, "_-")
+	if i < 0 {
+		return name, ""
+	}
+
+	if flavor, ok := tocSuffixes[strings.ToLower(name[i+1:])]; ok {
+		return name[:i], flavor
+	}
+
+	return name, ""
+}
+
+// ParseTOC reads the content of a .toc file. It handles a leading byte order mark
+// and CRLF line endings.
+func ParseTOC(r io.Reader) (*TOC, error) {
+	toc := &TOC{
+		Fields: make(map[string]string),
+	}
+
+	scanner := bufio.NewScanner(r)
+	first := true
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "##"):
+			if err := toc.parseField(line[2:]); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(line, "#"):
+			// comment
+		default:
+			toc.Files = append(toc.Files, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return toc, nil
+}
+
+func (t *TOC) parseField(line string) error {
+	i := strings.IndexByte(line, ':')
+	if i < 0 {
+		return nil
+	}
+
+	key := strings.TrimSpace(line[:i])
+	value := strings.TrimSpace(line[i+1:])
+	if key == "" {
+		return nil
+	}
+
+	t.Fields[key] = value
+
+	switch lower := strings.ToLower(key); {
+	case lower == "interface":
+		for _, v := range splitList(value) {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid interface %q", v)
+			}
+			t.Interface = append(t.Interface, n)
+		}
+	case lower == "title":
+		t.Title = StripEscapes(value)
+	case lower == "notes":
+		t.Notes = StripEscapes(value)
+	case lower == "version":
+		t.Version = value
+	case lower == "author":
+		t.Author = StripEscapes(value)
+	case lower == "optionaldeps":
+		t.OptionalDeps = append(t.OptionalDeps, splitList(value)...)
+	case lower == "dependencies", lower == "requireddeps", strings.HasPrefix(lower, "dep"):
+		t.Dependencies = append(t.Dependencies, splitList(value)...)
+	}
+
+	return nil
+}
+
+// Field returns the value of the metadata field with the given key. Keys are
+// compared case-insensitively, so X-Tukui-ProjectID matches X-TUKUI-PROJECTID.
+func (t *TOC) Field(key string) (string, bool) {
+	if v, ok := t.Fields[key]; ok {
+		return v, true
+	}
+
+	for k, v := range t.Fields {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// Extensions returns all X- extension fields, like X-Tukui-ProjectID or X-Website.
+func (t *TOC) Extensions() map[string]string {
+	extensions := make(map[string]string)
+	for k, v := range t.Fields {
+		if len(k) > 2 && strings.EqualFold(k[:2], "x-") {
+			extensions[k] = v
+		}
+	}
+
+	return extensions
+}
+
+// TukuiProjectID returns the value of the X-Tukui-ProjectID field, the ID of the
+// addon on tukui.org.
+func (t *TOC) TukuiProjectID() (string, bool) {
+	id, ok := t.Field("X-Tukui-ProjectID")
+	return id, ok && id != ""
+}
+
+// StripEscapes removes WoW UI escape sequences, like |cffff0000 colors or |T textures,
+// from s.
+func StripEscapes(s string) string {
+	return strings.TrimSpace(escapeSequences.ReplaceAllString(s, ""))
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
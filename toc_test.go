@@ -0,0 +1,160 @@
+package tukui
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseTOC(t *testing.T) {
+	content := "\ufeff## Interface: 100207, 40400, 11502\r\n" +
+		"## Title: |cff1784d1ElvUI|r |cfd9b9b9bOptions|r\r\n" +
+		"## Notes: Options for |cff1784d1ElvUI|r\r\n" +
+		"## Version: v13.64\r\n" +
+		"## Author: Elv, Simpy\r\n" +
+		"## RequiredDeps: ElvUI\r\n" +
+		"## Dependencies: ElvUI_Libraries\r\n" +
+		"## OptionalDeps: Masque, AddOnSkins\r\n" +
+		"## X-Tukui-ProjectID: -2\r\n" +
+		"## X-Website: https://tukui.org\r\n" +
+		"# a comment\r\n" +
+		"\r\n" +
+		"Core\\Load_Core.xml\r\n" +
+		"Locales\\Load_Locales.xml\r\n"
+
+	toc, err := ParseTOC(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseTOC() returned error: %v", err)
+	}
+
+	want := &TOC{
+		Interface:    []int{100207, 40400, 11502},
+		Title:        "ElvUI Options",
+		Notes:        "Options for ElvUI",
+		Version:      "v13.64",
+		Author:       "Elv, Simpy",
+		Dependencies: []string{"ElvUI", "ElvUI_Libraries"},
+		OptionalDeps: []string{"Masque", "AddOnSkins"},
+		Fields: map[string]string{
+			"Interface":         "100207, 40400, 11502",
+			"Title":             "|cff1784d1ElvUI|r |cfd9b9b9bOptions|r",
+			"Notes":             "Options for |cff1784d1ElvUI|r",
+			"Version":           "v13.64",
+			"Author":            "Elv, Simpy",
+			"RequiredDeps":      "ElvUI",
+			"Dependencies":      "ElvUI_Libraries",
+			"OptionalDeps":      "Masque, AddOnSkins",
+			"X-Tukui-ProjectID": "-2",
+			"X-Website":         "https://tukui.org",
+		},
+		Files: []string{`Core\Load_Core.xml`, `Locales\Load_Locales.xml`},
+	}
+
+	if !cmp.Equal(toc, want) {
+		t.Errorf("ParseTOC() returned diff %s", cmp.Diff(want, toc))
+	}
+
+	if id, ok := toc.TukuiProjectID(); !ok || id != "-2" {
+		t.Errorf("TOC.TukuiProjectID() returned %q, %v, want %q, true", id, ok, "-2")
+	}
+
+	wantExtensions := map[string]string{"X-Tukui-ProjectID": "-2", "X-Website": "https://tukui.org"}
+	if got := toc.Extensions(); !cmp.Equal(got, wantExtensions) {
+		t.Errorf("TOC.Extensions() returned %v, want %v", got, wantExtensions)
+	}
+}
+
+func TestParseTOC_DepPrefix(t *testing.T) {
+	toc, err := ParseTOC(strings.NewReader("## Dep1: Tukui\n## DepSkins: AddOnSkins\n"))
+	if err != nil {
+		t.Fatalf("ParseTOC() returned error: %v", err)
+	}
+
+	want := []string{"Tukui", "AddOnSkins"}
+	if !cmp.Equal(toc.Dependencies, want) {
+		t.Errorf("ParseTOC() returned dependencies %v, want %v", toc.Dependencies, want)
+	}
+}
+
+func TestParseTOC_InvalidInterface(t *testing.T) {
+	_, err := ParseTOC(strings.NewReader("## Interface: 9.0.2\n"))
+	if err == nil {
+		t.Errorf("ParseTOC() returned no error")
+	}
+}
+
+func TestTOC_Field(t *testing.T) {
+	toc, err := ParseTOC(strings.NewReader("## X-TUKUI-PROJECTID: 3\n"))
+	if err != nil {
+		t.Fatalf("ParseTOC() returned error: %v", err)
+	}
+
+	if v, ok := toc.Field("X-Tukui-ProjectID"); !ok || v != "3" {
+		t.Errorf("TOC.Field() returned %q, %v, want %q, true", v, ok, "3")
+	}
+
+	if _, ok := toc.Field("X-Curse-Project-ID"); ok {
+		t.Errorf("TOC.Field() found a missing field")
+	}
+}
+
+func TestParseTOCName(t *testing.T) {
+	tests := []struct {
+		filename string
+		name     string
+		flavor   Flavor
+	}{
+		{"ElvUI.toc", "ElvUI", ""},
+		{"ElvUI_Mainline.toc", "ElvUI", FlavorRetail},
+		{"ElvUI-Mainline.toc", "ElvUI", FlavorRetail},
+		{"ElvUI_Classic.toc", "ElvUI", FlavorClassic},
+		{"ElvUI_Vanilla.toc", "ElvUI", FlavorClassic},
+		{"ElvUI-BCC.toc", "ElvUI", FlavorBurningCrusade},
+		{"ElvUI_TBC.toc", "ElvUI", FlavorBurningCrusade},
+		{"ElvUI_Wrath.toc", "ElvUI", FlavorWrath},
+		{"ElvUI_Cata.toc", "ElvUI", FlavorCataclysm},
+		{"ElvUI_Options.toc", "ElvUI_Options", ""},
+		{"ElvUI_Options_Classic.toc", "ElvUI_Options", FlavorClassic},
+	}
+
+	for _, tt := range tests {
+		name, flavor := ParseTOCName(tt.filename)
+		if name != tt.name || flavor != tt.flavor {
+			t.Errorf("ParseTOCName(%q) returned %q, %q, want %q, %q", tt.filename, name, flavor, tt.name, tt.flavor)
+		}
+	}
+}
+
+func TestReadTOC(t *testing.T) {
+	path := filepath.Join(tempDir(t), "Tukui_Classic.toc")
+	if err := ioutil.WriteFile(path, []byte("## Interface: 11502\n## Title: Tukui\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	toc, err := ReadTOC(path)
+	if err != nil {
+		t.Fatalf("ReadTOC() returned error: %v", err)
+	}
+
+	if toc.Name != "Tukui" || toc.Flavor != FlavorClassic || toc.Title != "Tukui" {
+		t.Errorf("ReadTOC() returned %+v", toc)
+	}
+}
+
+func TestStripEscapes(t *testing.T) {
+	tests := map[string]string{
+		"|cff1784d1ElvUI|r":                         "ElvUI",
+		"|TInterface\\Icons\\Spell:16|t Tukui":      "Tukui",
+		"|A:groupfinder-icon-role-large-tank:0:0|a": "",
+		"Plain Title":                               "Plain Title",
+	}
+
+	for in, want := range tests {
+		if got := StripEscapes(in); got != want {
+			t.Errorf("StripEscapes(%q) returned %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+// Package tukuifake provides an in-memory implementation of tukui.AddonClient for
+// unit tests that should not depend on HTTP at all.
+package tukuifake
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/unly/go-tukui"
+)
+
+// AddonClient must implement every method of tukui.AddonClient.
+var _ tukui.AddonClient = (*AddonClient)(nil)
+
+// Method names a method of tukui.AddonClient.
+type Method string
+
+// The methods of tukui.AddonClient.
+const (
+	GetAddon       Method = "GetAddon"
+	GetAddons      Method = "GetAddons"
+	GetTukUI       Method = "GetTukUI"
+	GetElvUI       Method = "GetElvUI"
+	GetAddonBySlug Method = "GetAddonBySlug"
+	GetUI          Method = "GetUI"
+	ListUIs        Method = "ListUIs"
+)
+
+// A Call describes a call of a method.
+type Call struct {
+	// Method that was called
+	Method Method
+	// N counts the calls of the method, starting at 1
+	N int
+	// ID passed to GetAddon
+	ID int
+	// Slug passed to GetAddonBySlug
+	Slug string
+	// Name passed to GetUI
+	Name string
+}
+
+// A Hook is called for every call of a method before the result is returned. A non
+// nil error is returned by the method instead of the result.
+type Hook func(call Call) error
+
+// AddonClient is a tukui.AddonClient serving a settable set of addons. Errors can be
+// injected per method. It is safe for concurrent use.
+type AddonClient struct {
+	mu     sync.Mutex
+	addons []tukui.Addon
+	uis    map[string]tukui.Addon
+	errors map[Method]error
+	hooks  map[Method]Hook
+	calls  map[Method]int
+}
+
+// NewAddonClient returns an AddonClient serving the given addons.
+func NewAddonClient(addons ...tukui.Addon) *AddonClient {
+	c := &AddonClient{
+		uis:    make(map[string]tukui.Addon),
+		errors: make(map[Method]error),
+		hooks:  make(map[Method]Hook),
+		calls:  make(map[Method]int),
+	}
+	c.SetAddons(addons...)
+
+	return c
+}
+
+// SetAddons replaces the addons returned by GetAddon and GetAddons.
+func (c *AddonClient) SetAddons(addons ...tukui.Addon) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.addons = append([]tukui.Addon{}, addons...)
+}
+
+// SetTukUI sets the addon returned by GetTukUI.
+func (c *AddonClient) SetTukUI(addon tukui.Addon) {
+	c.SetUI("tukui", addon)
+}
+
+// SetElvUI sets the addon returned by GetElvUI.
+func (c *AddonClient) SetElvUI(addon tukui.Addon) {
+	c.SetUI("elvui", addon)
+}
+
+// SetUI sets the UI suite returned by GetUI for the name, case insensitive.
+func (c *AddonClient) SetUI(name string, addon tukui.Addon) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.uis[strings.ToLower(name)] = addon
+}
+
+// SetError makes every call of the method return err. A nil err removes the error.
+func (c *AddonClient) SetError(m Method, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		delete(c.errors, m)
+		return
+	}
+
+	c.errors[m] = err
+}
+
+// SetHook sets the hook called for every call of the method. A nil hook removes it.
+func (c *AddonClient) SetHook(m Method, h Hook) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if h == nil {
+		delete(c.hooks, m)
+		return
+	}
+
+	c.hooks[m] = h
+}
+
+// Calls returns the number of calls of the method.
+func (c *AddonClient) Calls(m Method) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.calls[m]
+}
+
+// ResetCalls sets the number of calls of all methods to zero.
+func (c *AddonClient) ResetCalls() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls = make(map[Method]int)
+}
+
+// call counts the call and returns the error of the method or its hook. The hook is
+// called without holding the lock, so it may use the AddonClient.
+func (c *AddonClient) call(call Call) error {
+	c.mu.Lock()
+	c.calls[call.Method]++
+	call.N = c.calls[call.Method]
+	err := c.errors[call.Method]
+	hook := c.hooks[call.Method]
+	c.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if hook != nil {
+		return hook(call)
+	}
+
+	return nil
+}
+
+func response() *http.Response {
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+	}
+}
+
+// GetAddon returns the addon with the given ID or tukui.ErrEmptyResponse, like the API.
+func (c *AddonClient) GetAddon(id int) (tukui.Addon, *http.Response, error) {
+	if err := c.call(Call{Method: GetAddon, ID: id}); err != nil {
+		return tukui.Addon{}, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	want := strconv.Itoa(id)
+	for _, addon := range c.addons {
+		if addon.Id != nil && *addon.Id == want {
+			return addon, response(), nil
+		}
+	}
+
+	return tukui.Addon{}, response(), tukui.ErrEmptyResponse
+}
+
+// GetAddons returns a copy of all addons.
+func (c *AddonClient) GetAddons() ([]tukui.Addon, *http.Response, error) {
+	if err := c.call(Call{Method: GetAddons}); err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]tukui.Addon{}, c.addons...), response(), nil
+}
+
+// GetTukUI returns the addon set with SetTukUI or tukui.ErrEmptyResponse.
+func (c *AddonClient) GetTukUI() (tukui.Addon, *http.Response, error) {
+	if err := c.call(Call{Method: GetTukUI}); err != nil {
+		return tukui.Addon{}, nil, err
+	}
+
+	return c.ui("tukui")
+}
+
+// GetElvUI returns the addon set with SetElvUI or tukui.ErrEmptyResponse.
+func (c *AddonClient) GetElvUI() (tukui.Addon, *http.Response, error) {
+	if err := c.call(Call{Method: GetElvUI}); err != nil {
+		return tukui.Addon{}, nil, err
+	}
+
+	return c.ui("elvui")
+}
+
+// GetAddonBySlug looks up the slug in the addons and the UI suites with
+// tukui.FindBySlug. The context is ignored.
+func (c *AddonClient) GetAddonBySlug(ctx context.Context, slug string) (tukui.Addon, *http.Response, error) {
+	if err := c.call(Call{Method: GetAddonBySlug, Slug: slug}); err != nil {
+		return tukui.Addon{}, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	addons := append(append([]tukui.Addon{}, c.addons...), c.sortedUIs()...)
+
+	addon, err := tukui.FindBySlug(addons, slug)
+
+	return addon, response(), err
+}
+
+// GetUI returns the UI suite set with SetUI or an error wrapping tukui.ErrUnknownUI.
+// The context is ignored.
+func (c *AddonClient) GetUI(ctx context.Context, name string) (tukui.Addon, *http.Response, error) {
+	if err := c.call(Call{Method: GetUI, Name: name}); err != nil {
+		return tukui.Addon{}, nil, err
+	}
+
+	addon, resp, err := c.ui(strings.ToLower(strings.TrimSpace(name)))
+	if err != nil {
+		return addon, resp, fmt.Errorf("%w %q", tukui.ErrUnknownUI, name)
+	}
+
+	return addon, resp, nil
+}
+
+// ListUIs returns the UI suites set with SetUI ordered by name. The context is ignored.
+func (c *AddonClient) ListUIs(ctx context.Context) ([]tukui.Addon, *http.Response, error) {
+	if err := c.call(Call{Method: ListUIs}); err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.sortedUIs(), response(), nil
+}
+
+func (c *AddonClient) ui(name string) (tukui.Addon, *http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	addon, ok := c.uis[name]
+	if !ok {
+		return tukui.Addon{}, response(), tukui.ErrEmptyResponse
+	}
+
+	return addon, response(), nil
+}
+
+// sortedUIs returns the UI suites ordered by name. The lock must be held.
+func (c *AddonClient) sortedUIs() []tukui.Addon {
+	names := make([]string, 0, len(c.uis))
+	for name := range c.uis {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	uis := make([]tukui.Addon, 0, len(names))
+	for _, name := range names {
+		uis = append(uis, c.uis[name])
+	}
+
+	return uis
+}
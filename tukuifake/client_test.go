@@ -0,0 +1,255 @@
+package tukuifake
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/unly/go-tukui"
+)
+
+func String(s string) *string {
+	return &s
+}
+
+func TestAddonClient_GetAddon(t *testing.T) {
+	skins := tukui.Addon{Id: String("3"), Name: String("AddOnSkins"), Version: String("3.53")}
+	client := NewAddonClient(skins, tukui.Addon{Name: String("no id")})
+
+	addon, resp, err := client.GetAddon(3)
+	if err != nil {
+		t.Fatalf("GetAddon() returned error: %v", err)
+	}
+
+	if !cmp.Equal(addon, skins) {
+		t.Errorf("GetAddon() returned %+v, want %+v", addon, skins)
+	}
+
+	if resp == nil || resp.StatusCode != 200 {
+		t.Errorf("GetAddon() returned response %+v, want 200 OK", resp)
+	}
+
+	if _, _, err := client.GetAddon(4); !errors.Is(err, tukui.ErrEmptyResponse) {
+		t.Errorf("GetAddon(4) returned error %v, want %v", err, tukui.ErrEmptyResponse)
+	}
+
+	client.SetAddons()
+	if _, _, err := client.GetAddon(3); !errors.Is(err, tukui.ErrEmptyResponse) {
+		t.Errorf("GetAddon(3) after SetAddons() returned error %v, want %v", err, tukui.ErrEmptyResponse)
+	}
+
+	if calls := client.Calls(GetAddon); calls != 3 {
+		t.Errorf("Calls() returned %d, want %d", calls, 3)
+	}
+}
+
+func TestAddonClient_GetAddons(t *testing.T) {
+	addons := []tukui.Addon{
+		{Id: String("3"), Name: String("AddOnSkins")},
+		{Id: String("6"), Name: String("LocationPlus")},
+	}
+	client := NewAddonClient(addons...)
+
+	got, _, err := client.GetAddons()
+	if err != nil {
+		t.Fatalf("GetAddons() returned error: %v", err)
+	}
+
+	if !cmp.Equal(got, addons) {
+		t.Errorf("GetAddons() returned %+v, want %+v", got, addons)
+	}
+
+	got[0].Name = String("changed")
+	if again, _, _ := client.GetAddons(); *again[0].Name != "AddOnSkins" {
+		t.Errorf("GetAddons() returned %q after changing the result, want %q", *again[0].Name, "AddOnSkins")
+	}
+}
+
+func TestAddonClient_UI(t *testing.T) {
+	client := NewAddonClient()
+
+	if _, _, err := client.GetTukUI(); !errors.Is(err, tukui.ErrEmptyResponse) {
+		t.Errorf("GetTukUI() returned error %v, want %v", err, tukui.ErrEmptyResponse)
+	}
+
+	elvui := tukui.Addon{Id: String("-2"), Name: String("ElvUI")}
+	tukuiAddon := tukui.Addon{Id: String("-1"), Name: String("Tukui")}
+	client.SetElvUI(elvui)
+	client.SetTukUI(tukuiAddon)
+
+	if got, _, err := client.GetElvUI(); err != nil || !cmp.Equal(got, elvui) {
+		t.Errorf("GetElvUI() returned %+v, %v, want %+v", got, err, elvui)
+	}
+
+	if got, _, err := client.GetTukUI(); err != nil || !cmp.Equal(got, tukuiAddon) {
+		t.Errorf("GetTukUI() returned %+v, %v, want %+v", got, err, tukuiAddon)
+	}
+}
+
+func TestAddonClient_SetError(t *testing.T) {
+	client := NewAddonClient(tukui.Addon{Id: String("3")})
+	errDown := errors.New("api down")
+
+	client.SetError(GetAddons, errDown)
+
+	if _, _, err := client.GetAddons(); !errors.Is(err, errDown) {
+		t.Errorf("GetAddons() returned error %v, want %v", err, errDown)
+	}
+
+	if _, _, err := client.GetAddon(3); err != nil {
+		t.Errorf("GetAddon() returned error %v, want nil", err)
+	}
+
+	dir, err := ioutil.TempDir("", "tukuifake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := tukui.Scan(dir, tukui.FlavorRetail, client); !errors.Is(err, errDown) {
+		t.Errorf("Scan() returned error %v, want %v", err, errDown)
+	}
+
+	client.SetError(GetAddons, nil)
+	if _, _, err := client.GetAddons(); err != nil {
+		t.Errorf("GetAddons() after removing the error returned %v, want nil", err)
+	}
+
+	if calls := client.Calls(GetAddons); calls != 3 {
+		t.Errorf("Calls() returned %d, want %d", calls, 3)
+	}
+
+	client.ResetCalls()
+	if calls := client.Calls(GetAddons); calls != 0 {
+		t.Errorf("Calls() after ResetCalls() returned %d, want %d", calls, 0)
+	}
+}
+
+func TestAddonClient_SetHook(t *testing.T) {
+	client := NewAddonClient(tukui.Addon{Id: String("3")})
+	errFlaky := errors.New("flaky")
+
+	var calls []Call
+	client.SetHook(GetAddon, func(call Call) error {
+		calls = append(calls, call)
+		if call.N == 1 {
+			return errFlaky
+		}
+		// the hook may use the client without deadlocking
+		client.Calls(GetAddon)
+		return nil
+	})
+
+	if _, _, err := client.GetAddon(3); !errors.Is(err, errFlaky) {
+		t.Errorf("first GetAddon() returned error %v, want %v", err, errFlaky)
+	}
+
+	if _, _, err := client.GetAddon(3); err != nil {
+		t.Errorf("second GetAddon() returned error %v, want nil", err)
+	}
+
+	want := []Call{{Method: GetAddon, N: 1, ID: 3}, {Method: GetAddon, N: 2, ID: 3}}
+	if !cmp.Equal(calls, want) {
+		t.Errorf("hook was called with %+v, want %+v", calls, want)
+	}
+
+	client.SetHook(GetAddon, nil)
+	if _, _, err := client.GetAddon(3); err != nil || len(calls) != 2 {
+		t.Errorf("GetAddon() after removing the hook returned %v and called the hook %d times", err, len(calls))
+	}
+}
+
+func TestAddonClient_Concurrent(t *testing.T) {
+	client := NewAddonClient(tukui.Addon{Id: String("3")})
+
+	var wg sync.WaitGroup
+	for n := 0; n < 10; n++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			client.GetAddons()
+			client.GetAddon(3)
+		}()
+		go func() {
+			defer wg.Done()
+			client.SetAddons(tukui.Addon{Id: String("3")})
+			client.SetElvUI(tukui.Addon{})
+		}()
+	}
+	wg.Wait()
+
+	if calls := client.Calls(GetAddons); calls != 10 {
+		t.Errorf("Calls() returned %d, want %d", calls, 10)
+	}
+}
+
+func TestAddonClient_GetAddonBySlug(t *testing.T) {
+	client := NewAddonClient(tukui.Addon{Id: String("3"), Name: String("AddOnSkins")})
+	client.SetElvUI(tukui.Addon{Id: String("-2"), Name: String("ElvUI")})
+
+	var calls []Call
+	client.SetHook(GetAddonBySlug, func(call Call) error {
+		calls = append(calls, call)
+		return nil
+	})
+
+	for slug, want := range map[string]string{"addonskins": "3", "elvui": "-2"} {
+		addon, _, err := client.GetAddonBySlug(context.Background(), slug)
+		if err != nil {
+			t.Fatalf("GetAddonBySlug(%q) returned error: %v", slug, err)
+		}
+
+		if *addon.Id != want {
+			t.Errorf("GetAddonBySlug(%q) returned id %s, want %s", slug, *addon.Id, want)
+		}
+	}
+
+	if _, _, err := client.GetAddonBySlug(context.Background(), "tukui"); !errors.Is(err, tukui.ErrEmptyResponse) {
+		t.Errorf("GetAddonBySlug() returned error %v, want %v", err, tukui.ErrEmptyResponse)
+	}
+
+	if len(calls) != 3 || calls[2] != (Call{Method: GetAddonBySlug, N: 3, Slug: "tukui"}) {
+		t.Errorf("hook was called with %+v", calls)
+	}
+}
+
+func TestAddonClient_GetUI(t *testing.T) {
+	client := NewAddonClient()
+	client.SetTukUI(tukui.Addon{Id: String("-1"), Name: String("Tukui")})
+	client.SetUI("ShadowAndLight", tukui.Addon{Id: String("-3"), Name: String("Shadow & Light")})
+
+	ui, _, err := client.GetUI(context.Background(), "shadowandlight")
+	if err != nil || *ui.Id != "-3" {
+		t.Errorf("GetUI() returned %+v, %v, want id -3", ui, err)
+	}
+
+	if _, _, err := client.GetUI(context.Background(), "elvui"); !errors.Is(err, tukui.ErrUnknownUI) {
+		t.Errorf("GetUI() returned error %v, want %v", err, tukui.ErrUnknownUI)
+	}
+
+	if _, _, err := client.GetElvUI(); !errors.Is(err, tukui.ErrEmptyResponse) {
+		t.Errorf("GetElvUI() returned error %v, want %v", err, tukui.ErrEmptyResponse)
+	}
+
+	uis, _, err := client.ListUIs(context.Background())
+	if err != nil {
+		t.Fatalf("ListUIs() returned error: %v", err)
+	}
+
+	want := []tukui.Addon{
+		{Id: String("-3"), Name: String("Shadow & Light")},
+		{Id: String("-1"), Name: String("Tukui")},
+	}
+
+	if !cmp.Equal(uis, want) {
+		t.Errorf("ListUIs() returned %+v, want %+v", uis, want)
+	}
+
+	if calls := client.Calls(GetUI); calls != 2 {
+		t.Errorf("Calls(GetUI) returned %d, want %d", calls, 2)
+	}
+}
@@ -0,0 +1,263 @@
+package tukuitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrUnrecorded is returned when replaying a request without a fixture.
+var ErrUnrecorded = errors.New("unrecorded request")
+
+// Mode selects whether a Recorder records or replays.
+type Mode int
+
+const (
+	// Replay answers requests from the fixtures only, it never uses the network
+	Replay Mode = iota
+	// Record sends requests to the server and writes the responses to fixtures
+	Record
+)
+
+// RedactedHeaders are the response headers that are not written to fixtures, as
+// they change with every response or identify a session.
+var RedactedHeaders = []string{
+	"Age",
+	"Cf-Cache-Status",
+	"Cf-Ray",
+	"Date",
+	"Expect-Ct",
+	"Expires",
+	"Nel",
+	"Report-To",
+	"Server",
+	"Set-Cookie",
+	"X-Request-Id",
+}
+
+// A Fixture is a recorded request/response pair.
+type Fixture struct {
+	// Method of the request
+	Method string `json:"method"`
+	// Host of the request, e.g. api.tukui.org
+	Host string `json:"host"`
+	// Path of the request, escaped
+	Path string `json:"path"`
+	// Query of the request, normalized
+	Query string `json:"query"`
+	// Status code of the response
+	Status int `json:"status"`
+	// Header of the response without the redacted headers
+	Header http.Header `json:"header,omitempty"`
+	// Body of the response if it is valid UTF-8
+	Body string `json:"body,omitempty"`
+	// BodyBase64 holds other bodies, like .zip files
+	BodyBase64 []byte `json:"body_base64,omitempty"`
+}
+
+// Recorder is an http.RoundTripper recording responses into fixture files and
+// replaying them. Fixtures are keyed by the method, the host, the path and the
+// normalized query of the request. It is safe for concurrent use.
+//
+//	recorder := tukuitest.NewRecorder("testdata/fixtures", tukuitest.Replay)
+//	client := tukui.NewClient(recorder.Client())
+type Recorder struct {
+	// Dir holds one fixture file per request
+	Dir string
+	// Mode of the Recorder
+	Mode Mode
+	// Transport sends the requests while recording, nil uses http.DefaultTransport
+	Transport http.RoundTripper
+
+	mu sync.Mutex
+}
+
+// NewRecorder returns a Recorder using the fixtures in dir.
+func NewRecorder(dir string, mode Mode) *Recorder {
+	return &Recorder{Dir: dir, Mode: mode}
+}
+
+// Client returns an http.Client using the Recorder, to be passed to tukui.NewClient.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip records or replays the request.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	if r.Mode == Record {
+		return r.record(req)
+	}
+
+	return r.replay(req)
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	key := fixtureKey(req.URL.Host, requestPath(req), normalize(req.URL.RawQuery))
+
+	r.mu.Lock()
+	data, err := ioutil.ReadFile(r.path(req.Method, key))
+	r.mu.Unlock()
+
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s %s", ErrUnrecorded, req.Method, req.URL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("fixture for %s %s: %w", req.Method, key, err)
+	}
+
+	body := f.BodyBase64
+	if body == nil {
+		body = []byte(f.Body)
+	}
+
+	return response(req, f.Status, f.Header, body), nil
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	header := resp.Header.Clone()
+	for _, key := range RedactedHeaders {
+		header.Del(key)
+	}
+
+	f := Fixture{
+		Method: req.Method,
+		Host:   req.URL.Host,
+		Path:   requestPath(req),
+		Query:  normalize(req.URL.RawQuery),
+		Status: resp.StatusCode,
+		Header: header,
+	}
+
+	if utf8.Valid(body) {
+		f.Body = string(body)
+	} else {
+		f.BodyBase64 = body
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(r.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(r.path(f.Method, fixtureKey(f.Host, f.Path, f.Query)), append(data, '\n'), 0644); err != nil {
+		return nil, err
+	}
+
+	return response(req, f.Status, header, body), nil
+}
+
+// Fixtures returns the keys of the recorded fixtures, like
+// "GET www.tukui.org/api.php?addons=all".
+func (r *Recorder) Fixtures() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(r.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		i := strings.Index(name, "_")
+		if i < 0 {
+			continue
+		}
+
+		key, err := url.QueryUnescape(name[i+1:])
+		if err != nil {
+			continue
+		}
+
+		keys = append(keys, name[:i]+" "+key)
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// path returns the fixture file for a request key, e.g.
+// "GET_www.tukui.org%2Fapi.php%3Faddons%3Dall.json".
+func (r *Recorder) path(method, key string) string {
+	return filepath.Join(r.Dir, method+"_"+url.QueryEscape(key)+".json")
+}
+
+// fixtureKey joins the parts of a request URL that identify its fixture. Downloads
+// and the v1 API have no query, so the host and the path are part of the key.
+func fixtureKey(host, path, query string) string {
+	key := host + path
+	if query != "" {
+		key += "?" + query
+	}
+
+	return key
+}
+
+func requestPath(req *http.Request) string {
+	if path := req.URL.EscapedPath(); path != "" {
+		return path
+	}
+
+	return "/"
+}
+
+func response(req *http.Request, status int, header http.Header, body []byte) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
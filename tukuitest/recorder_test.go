@@ -0,0 +1,160 @@
+package tukuitest
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/unly/go-tukui"
+)
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tukuitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := NewServer()
+
+	addons := []tukui.Addon{{Id: String("3"), Name: String("AddOnSkins"), Version: String("3.53")}}
+	if err := server.SetAddons(tukui.FlavorRetail, addons...); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := NewRecorder(dir, Record)
+	client := tukui.NewClient(recorder.Client())
+	client.SetBaseURL(server.URL)
+
+	if _, _, err := client.RetailAddons.GetAddons(); err != nil {
+		t.Fatalf("GetAddons() while recording returned error: %v", err)
+	}
+
+	if _, _, err := client.RetailAddons.GetAddon(3); err != nil {
+		t.Fatalf("GetAddon() while recording returned error: %v", err)
+	}
+
+	server.Close()
+
+	keys, err := recorder.Fixtures()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api := strings.TrimPrefix(server.URL, "http://")
+	wantKeys := []string{"GET " + api + "?addon=3", "GET " + api + "?addons=all"}
+	if !cmp.Equal(keys, wantKeys) {
+		t.Errorf("Fixtures() returned %v, want %v", keys, wantKeys)
+	}
+
+	recorder.Mode = Replay
+
+	got, resp, err := client.RetailAddons.GetAddons()
+	if err != nil {
+		t.Fatalf("GetAddons() while replaying returned error: %v", err)
+	}
+
+	if !cmp.Equal(got, addons) {
+		t.Errorf("GetAddons() returned %+v, want %+v", got, addons)
+	}
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("GetAddons() returned response %d with header %v", resp.StatusCode, resp.Header)
+	}
+
+	if date := resp.Header.Get("Date"); date != "" {
+		t.Errorf("GetAddons() returned the recorded Date header %q, want it redacted", date)
+	}
+
+	if _, _, err := client.RetailAddons.GetAddon(6); !errors.Is(err, ErrUnrecorded) {
+		t.Errorf("GetAddon() of an unrecorded addon returned error %v, want %v", err, ErrUnrecorded)
+	}
+}
+
+func TestRecorder_Binary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tukuitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	payload := []byte{'P', 'K', 3, 4, 0xff, 0xfe}
+	server := NewServer()
+	server.SetResponse("download=3", payload)
+	defer server.Close()
+
+	recorder := NewRecorder(dir, Record)
+	for _, mode := range []Mode{Record, Replay} {
+		recorder.Mode = mode
+
+		resp, err := recorder.Client().Get(server.URL + "?download=3")
+		if err != nil {
+			t.Fatalf("Get() in mode %d returned error: %v", mode, err)
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !cmp.Equal(body, payload) {
+			t.Errorf("Get() in mode %d returned %v, want %v", mode, body, payload)
+		}
+	}
+}
+
+func TestRecorder_Paths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tukuitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/download/ElvUI.zip", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("elvui"))
+	})
+	mux.HandleFunc("/download/Tukui.zip", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tukui"))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	recorder := NewRecorder(dir, Record)
+	for _, mode := range []Mode{Record, Replay} {
+		recorder.Mode = mode
+
+		for _, name := range []string{"ElvUI", "Tukui"} {
+			resp, err := recorder.Client().Get(server.URL + "/download/" + name + ".zip")
+			if err != nil {
+				t.Fatalf("Get() in mode %d returned error: %v", mode, err)
+			}
+
+			body, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := strings.ToLower(name); string(body) != want {
+				t.Errorf("Get() of %s in mode %d returned %q, want %q", name, mode, body, want)
+			}
+		}
+	}
+
+	keys, err := recorder.Fixtures()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 2 {
+		t.Errorf("Fixtures() returned %v, want one fixture per path", keys)
+	}
+}
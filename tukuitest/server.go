@@ -0,0 +1,355 @@
+// Package tukuitest provides a fake TukUI API server for tests.
+//
+// The Server answers the queries of the RetailAddons and ClassicAddons clients from
+// addons set as Go values or loaded from fixture files. Faults like latency, error
+// status codes, HTML error pages and malformed JSON can be injected per query, and
+// every received request is recorded.
+//
+//	server := tukuitest.NewServer()
+//	defer server.Close()
+//
+//	server.SetAddons(tukui.FlavorRetail, addons...)
+//	server.Inject("addons=all", tukuitest.Fault{Status: http.StatusServiceUnavailable, Times: 1})
+//
+//	client := server.Client()
+//
+// The Recorder records the responses of a real API into fixture files once and
+// replays them offline afterwards. The FaultTransport wraps the http.Client of any
+// tukui.Client and injects seeded network faults.
+package tukuitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/unly/go-tukui"
+)
+
+// Path is the path of the fake api.php below the URL of the Server.
+const Path = "/api.php"
+
+// A Fault changes the responses to a query.
+type Fault struct {
+	// Delay before the response is written. A canceled request stops waiting.
+	Delay time.Duration
+	// Status code of the response, 0 keeps 200 OK
+	Status int
+	// HTML replaces the body with an HTML error page, like a proxy in front of the API
+	HTML bool
+	// Malformed cuts the JSON body in half
+	Malformed bool
+	// Times is the number of requests the fault applies to, 0 applies it to all
+	Times int
+}
+
+// A Request is a request received by the Server.
+type Request struct {
+	// Method of the request
+	Method string
+	// Query of the request, e.g. addons=all
+	Query string
+	// Header of the request
+	Header http.Header
+}
+
+// Server is a fake api.php. It is safe for concurrent use.
+type Server struct {
+	// URL of the api.php, to be used with tukui.Client.SetBaseURL
+	URL string
+
+	server    *httptest.Server
+	mu        sync.Mutex
+	responses map[string][]byte
+	faults    map[string]*Fault
+	requests  []Request
+}
+
+// NewServer starts a Server without any addons. It has to be closed by the caller.
+func NewServer() *Server {
+	s := &Server{
+		responses: make(map[string][]byte),
+		faults:    make(map[string]*Fault),
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(Path, s)
+
+	s.server = httptest.NewServer(mux)
+	s.URL = s.server.URL + Path
+
+	return s
+}
+
+// Close shuts down the Server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns a tukui.Client using the Server.
+func (s *Server) Client() *tukui.Client {
+	client := tukui.NewClient(s.server.Client())
+	client.SetBaseURL(s.URL)
+
+	return client
+}
+
+// SetAddons replaces the addons of the retail or classic catalog. Each addon can
+// be queried by its ID and all of them with the list query. Classic clients look up
+// the UI suites by name in the list.
+func (s *Server) SetAddons(flavor tukui.Flavor, addons ...tukui.Addon) error {
+	single, list := "addon", "addons"
+	switch flavor {
+	case tukui.FlavorRetail:
+	case tukui.FlavorClassic:
+		single, list = "classic-addon", "classic-addons"
+	default:
+		return fmt.Errorf("%w: %s", tukui.ErrUnsupportedFlavor, flavor)
+	}
+
+	if addons == nil {
+		addons = []tukui.Addon{}
+	}
+
+	body, err := json.Marshal(addons)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for query := range s.responses {
+		if strings.HasPrefix(query, single+"=") {
+			delete(s.responses, query)
+		}
+	}
+
+	s.responses[query(list, "all")] = body
+
+	for _, addon := range addons {
+		if addon.Id == nil {
+			continue
+		}
+
+		body, err := json.Marshal(addon)
+		if err != nil {
+			return err
+		}
+		s.responses[query(single, *addon.Id)] = body
+	}
+
+	return nil
+}
+
+// SetUI sets the retail UI suite served by the ui query for the name, e.g. "elvui".
+// Like the real API, the ID and the downloads are encoded as numbers.
+func (s *Server) SetUI(name string, addon tukui.Addon) error {
+	data, err := json.Marshal(addon)
+	if err != nil {
+		return err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	for _, key := range []string{"id", "downloads"} {
+		if v, ok := fields[key].(string); ok {
+			if n, err := strconv.Atoi(v); err == nil {
+				fields[key] = n
+			}
+		}
+	}
+
+	if v, ok := fields["last_download"]; ok {
+		delete(fields, "last_download")
+		fields["lastdownload"] = v
+	}
+
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	s.SetResponse(query("ui", strings.ToLower(name)), body)
+
+	return nil
+}
+
+// SetResponse sets the raw body returned for a query like "addons=all". A nil body
+// removes the response, unknown queries return an empty body like the real API.
+func (s *Server) SetResponse(query string, body []byte) {
+	query = normalize(query)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if body == nil {
+		delete(s.responses, query)
+		return
+	}
+
+	s.responses[query] = body
+}
+
+// LoadFixtures reads the responses from the .json files in dir. Each file holds the
+// body for the query it is named after, e.g. "addons=all.json" or "ui=elvui.json".
+func (s *Server) LoadFixtures(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		if !strings.Contains(name, "=") {
+			continue
+		}
+
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		s.SetResponse(name, body)
+	}
+
+	return nil
+}
+
+// Inject applies the fault to the responses of a query like "classic-addons=all".
+// An empty query applies the fault to every query without a fault of its own.
+func (s *Server) Inject(query string, f Fault) {
+	if query != "" {
+		query = normalize(query)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults[query] = &f
+}
+
+// ClearFaults removes all injected faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = make(map[string]*Fault)
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// Queries returns the queries of the requests received so far.
+func (s *Server) Queries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	queries := make([]string, len(s.requests))
+	for i, r := range s.requests {
+		queries[i] = r.Query
+	}
+
+	return queries
+}
+
+// ResetRequests forgets the received requests.
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = nil
+}
+
+// ServeHTTP answers a query of the api.php.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := normalize(r.URL.RawQuery)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Query: q, Header: r.Header.Clone()})
+	body := s.responses[q]
+	fault := s.takeFault(q)
+	s.mu.Unlock()
+
+	if fault.Delay > 0 {
+		select {
+		case <-time.After(fault.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	status := http.StatusOK
+	if fault.Status != 0 {
+		status = fault.Status
+	}
+
+	contentType := "application/json"
+	switch {
+	case fault.HTML:
+		contentType = "text/html; charset=UTF-8"
+		body = []byte(fmt.Sprintf("<!DOCTYPE html>\n<html><head><title>%d %s</title></head><body><h1>%[2]s</h1></body></html>\n", status, http.StatusText(status)))
+	case fault.Malformed:
+		if len(body) < 2 {
+			body = []byte(`[{"id": "1", "name": `)
+		}
+		body = body[:len(body)/2]
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// takeFault returns the fault for the query and counts its use. The caller must hold the lock.
+func (s *Server) takeFault(query string) Fault {
+	f, ok := s.faults[query]
+	if !ok {
+		query = ""
+		if f, ok = s.faults[query]; !ok {
+			return Fault{}
+		}
+	}
+
+	fault := *f
+	if f.Times > 0 {
+		f.Times--
+		if f.Times == 0 {
+			delete(s.faults, query)
+		}
+	}
+
+	return fault
+}
+
+func query(key, value string) string {
+	return url.Values{key: {value}}.Encode()
+}
+
+// normalize returns the query with its parameters sorted and encoded the same way
+// the clients encode them.
+func normalize(query string) string {
+	values, err := url.ParseQuery(strings.TrimPrefix(query, "?"))
+	if err != nil {
+		return query
+	}
+
+	return values.Encode()
+}
@@ -0,0 +1,214 @@
+package tukuitest
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/unly/go-tukui"
+)
+
+func String(s string) *string {
+	return &s
+}
+
+func TestServer_SetAddons(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	retail := []tukui.Addon{
+		{Id: String("3"), Name: String("AddOnSkins"), Version: String("3.53")},
+		{Id: String("6"), Name: String("LocationPlus"), Version: String("2.48")},
+	}
+	classic := []tukui.Addon{
+		{Id: String("2"), Name: String("ElvUI"), Version: String("1.31")},
+	}
+
+	if err := server.SetAddons(tukui.FlavorRetail, retail...); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.SetAddons(tukui.FlavorClassic, classic...); err != nil {
+		t.Fatal(err)
+	}
+
+	elvui := tukui.Addon{Id: String("-2"), Name: String("ElvUI"), Downloads: String("100"), LastDownload: String("2020-09-21")}
+	if err := server.SetUI("elvui", elvui); err != nil {
+		t.Fatal(err)
+	}
+
+	client := server.Client()
+
+	addons, _, err := client.RetailAddons.GetAddons()
+	if err != nil || !cmp.Equal(addons, retail) {
+		t.Errorf("GetAddons() returned %+v, %v, want %+v", addons, err, retail)
+	}
+
+	addon, _, err := client.RetailAddons.GetAddon(6)
+	if err != nil || !cmp.Equal(addon, retail[1]) {
+		t.Errorf("GetAddon() returned %+v, %v, want %+v", addon, err, retail[1])
+	}
+
+	addon, _, err = client.ClassicAddons.GetElvUI()
+	if err != nil || !cmp.Equal(addon, classic[0]) {
+		t.Errorf("ClassicAddons.GetElvUI() returned %+v, %v, want %+v", addon, err, classic[0])
+	}
+
+	addon, _, err = client.RetailAddons.GetElvUI()
+	if err != nil || !cmp.Equal(addon, elvui) {
+		t.Errorf("RetailAddons.GetElvUI() returned %+v, %v, want %+v", addon, err, elvui)
+	}
+
+	if _, _, err := client.RetailAddons.GetAddon(99); !errors.Is(err, tukui.ErrEmptyResponse) {
+		t.Errorf("GetAddon() for unknown addon returned %v, want %v", err, tukui.ErrEmptyResponse)
+	}
+
+	want := []string{"addons=all", "addon=6", "classic-addons=all", "ui=elvui", "addon=99"}
+	if got := server.Queries(); !cmp.Equal(got, want) {
+		t.Errorf("Server.Queries() returned %v, want %v", got, want)
+	}
+
+	if r := server.Requests()[0]; r.Method != http.MethodGet {
+		t.Errorf("Server.Requests() recorded method %s", r.Method)
+	}
+
+	server.ResetRequests()
+	if got := server.Requests(); len(got) != 0 {
+		t.Errorf("Server.ResetRequests() left %v", got)
+	}
+}
+
+func TestServer_LoadFixtures(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	if err := server.LoadFixtures("testdata"); err != nil {
+		t.Fatalf("Server.LoadFixtures() returned error: %v", err)
+	}
+
+	client := server.Client()
+
+	addons, _, err := client.RetailAddons.GetAddons()
+	if err != nil || len(addons) != 1 || *addons[0].Author != "Azilroka" {
+		t.Errorf("GetAddons() returned %+v, %v", addons, err)
+	}
+
+	elvui, _, err := client.RetailAddons.GetElvUI()
+	if err != nil || *elvui.Id != "-2" || *elvui.Downloads != "38945820" {
+		t.Errorf("GetElvUI() returned %+v, %v", elvui, err)
+	}
+}
+
+func TestServer_Inject(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	if err := server.SetAddons(tukui.FlavorRetail, tukui.Addon{Id: String("3"), Name: String("AddOnSkins")}); err != nil {
+		t.Fatal(err)
+	}
+
+	client := server.Client()
+
+	server.Inject("addons=all", Fault{Malformed: true, Times: 1})
+	if _, _, err := client.RetailAddons.GetAddons(); err == nil {
+		t.Errorf("GetAddons() with malformed JSON returned %v", err)
+	}
+
+	if _, _, err := client.RetailAddons.GetAddons(); err != nil {
+		t.Errorf("GetAddons() after the fault returned %v", err)
+	}
+
+	server.Inject("", Fault{Status: http.StatusServiceUnavailable, HTML: true})
+	_, resp, err := client.RetailAddons.GetAddon(3)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Content-Type") != "text/html; charset=UTF-8" {
+		t.Errorf("GetAddon() with HTML error page returned %v, %v", resp, err)
+	}
+
+	server.ClearFaults()
+	server.Inject("addon=3", Fault{Delay: time.Second})
+
+	httpClient := &http.Client{Timeout: 50 * time.Millisecond}
+	slow := tukui.NewClient(httpClient)
+	slow.SetBaseURL(server.URL)
+
+	start := time.Now()
+	if _, _, err := slow.RetailAddons.GetAddon(3); err == nil {
+		t.Errorf("GetAddon() with delay returned no error")
+	}
+
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("GetAddon() with delay took %v", time.Since(start))
+	}
+}
+
+func TestServer_ClassicCatalogQueries(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	classic := []tukui.Addon{
+		{Id: String("2"), Name: String("ElvUI"), Version: String("1.31"), Category: String("Full UI Replacements")},
+		{Id: String("3"), Name: String("AddOnSkins"), Version: String("1.10")},
+	}
+	if err := server.SetAddons(tukui.FlavorClassic, classic...); err != nil {
+		t.Fatal(err)
+	}
+
+	client := server.Client()
+
+	installed := []tukui.InstalledAddon{
+		{Addon: &classic[0], Folders: []tukui.InstalledFolder{{Name: "ElvUI", TOC: &tukui.TOC{Version: "1.31"}}}},
+		{Addon: &tukui.Addon{Id: String("-1"), Name: String("Tukui")}, Folders: []tukui.InstalledFolder{{Name: "Tukui", TOC: &tukui.TOC{Version: "1.0"}}}},
+		{Addon: &classic[1], Folders: []tukui.InstalledFolder{{Name: "AddOnSkins", TOC: &tukui.TOC{Version: "1.09"}}}},
+	}
+
+	updates, err := client.CheckUpdates(installed, tukui.FlavorClassic)
+	if err != nil {
+		t.Fatalf("Client.CheckUpdates() returned error: %v", err)
+	}
+
+	var statuses []tukui.UpdateStatus
+	for _, u := range updates {
+		statuses = append(statuses, u.Status)
+	}
+
+	// Tukui has no classic version
+	if want := []tukui.UpdateStatus{tukui.StatusUpToDate, tukui.StatusUnknown, tukui.StatusOutdated}; !cmp.Equal(statuses, want) {
+		t.Errorf("Client.CheckUpdates() returned %v, want %v", statuses, want)
+	}
+
+	if got, want := server.Queries(), []string{"classic-addons=all"}; !cmp.Equal(got, want) {
+		t.Errorf("Client.CheckUpdates() made queries %v, want %v", got, want)
+	}
+
+	dir, err := ioutil.TempDir("", "tukuitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, folder := range []string{"ElvUI", "AddOnSkins"} {
+		os.Mkdir(filepath.Join(dir, folder), 0755)
+		if err := ioutil.WriteFile(filepath.Join(dir, folder, folder+".toc"), []byte("## Title: "+folder+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server.ResetRequests()
+
+	result, err := tukui.Scan(dir, tukui.FlavorClassic, client.ClassicAddons)
+	if err != nil {
+		t.Fatalf("Scan() returned error: %v", err)
+	}
+
+	if len(result.Addons) != 2 {
+		t.Errorf("Scan() matched %d addons, want 2", len(result.Addons))
+	}
+
+	if got, want := server.Queries(), []string{"classic-addons=all"}; !cmp.Equal(got, want) {
+		t.Errorf("Scan() made queries %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,219 @@
+package tukuitest
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// A TransportFault changes the responses of a FaultTransport to a query.
+type TransportFault struct {
+	// Delay before the request is sent. A canceled request stops waiting.
+	Delay time.Duration
+	// Jitter adds a random delay of up to Jitter to Delay
+	Jitter time.Duration
+	// Reset fails the request with a connection reset, syscall.ECONNRESET
+	Reset bool
+	// Status answers with the status code, e.g. 429 or 503, without sending the request
+	Status int
+	// RetryAfter sets the Retry-After header of a Status response in seconds
+	RetryAfter time.Duration
+	// Truncate cuts the body in half, reading it fails with io.ErrUnexpectedEOF
+	Truncate bool
+	// Corrupt replaces a random byte of the body with a NUL byte, which is never valid JSON
+	Corrupt bool
+	// Probability of the fault for each request, 0 applies it to every request
+	Probability float64
+	// Times is the number of requests the fault applies to, 0 applies it to all
+	Times int
+}
+
+// FaultTransport is an http.RoundTripper injecting faults into the requests of a
+// client. Random decisions, like the Jitter or the Probability of a fault, come from
+// a seeded source, so a test behaves the same on every run. It is safe for
+// concurrent use.
+//
+//	faults := tukuitest.WrapClient(http.DefaultClient, 42)
+//	faults.Inject("classic-addons=all", tukuitest.TransportFault{Status: http.StatusTooManyRequests, RetryAfter: time.Second, Times: 1})
+//	client := tukui.NewClient(faults.Client())
+type FaultTransport struct {
+	client  http.Client
+	base    http.RoundTripper
+	mu      sync.Mutex
+	rand    *rand.Rand
+	scripts map[string][]*TransportFault
+}
+
+// WrapClient returns a FaultTransport sending the requests with the transport of c.
+// A nil c wraps http.DefaultClient.
+func WrapClient(c *http.Client, seed int64) *FaultTransport {
+	if c == nil {
+		c = http.DefaultClient
+	}
+
+	t := &FaultTransport{
+		client:  *c,
+		base:    c.Transport,
+		rand:    rand.New(rand.NewSource(seed)),
+		scripts: make(map[string][]*TransportFault),
+	}
+
+	if t.base == nil {
+		t.base = http.DefaultTransport
+	}
+	t.client.Transport = t
+
+	return t
+}
+
+// Client returns a copy of the wrapped client using the FaultTransport, to be passed
+// to tukui.NewClient.
+func (t *FaultTransport) Client() *http.Client {
+	c := t.client
+	return &c
+}
+
+// Inject scripts the faults of a query like "classic-addons=all". The faults apply in
+// order, each to the next Times requests, and the requests after the script pass
+// through. An empty query scripts every query without a script of its own.
+func (t *FaultTransport) Inject(query string, faults ...TransportFault) {
+	if query != "" {
+		query = normalize(query)
+	}
+
+	script := make([]*TransportFault, len(faults))
+	for i := range faults {
+		f := faults[i]
+		script[i] = &f
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.scripts[query] = script
+}
+
+// ClearFaults removes all scripts.
+func (t *FaultTransport) ClearFaults() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.scripts = make(map[string][]*TransportFault)
+}
+
+// RoundTrip sends the request, applying the next fault scripted for its query.
+func (t *FaultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fault, delay, corruptAt := t.next(normalize(req.URL.RawQuery))
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+
+	if fault.Reset {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+	}
+
+	if fault.Status != 0 {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return statusResponse(req, fault.Status, fault.RetryAfter), nil
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !fault.Truncate && !fault.Corrupt {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if fault.Corrupt && len(body) > 0 {
+		body[int(corruptAt*float64(len(body)))] = 0
+	}
+
+	if fault.Truncate {
+		resp.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body[:len(body)/2]), errReader{io.ErrUnexpectedEOF}))
+	} else {
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	return resp, nil
+}
+
+// next takes the fault for the query and draws its random values.
+func (t *FaultTransport) next(query string) (TransportFault, time.Duration, float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	script, ok := t.scripts[query]
+	if !ok {
+		query = ""
+		script = t.scripts[query]
+	}
+
+	if len(script) == 0 {
+		return TransportFault{}, 0, 0
+	}
+
+	// the values are always drawn, so the sequence does not depend on the faults
+	chance, jitter, corruptAt := t.rand.Float64(), t.rand.Float64(), t.rand.Float64()
+
+	f := script[0]
+	if f.Probability > 0 && chance >= f.Probability {
+		return TransportFault{}, 0, 0
+	}
+
+	if f.Times > 0 {
+		f.Times--
+		if f.Times == 0 {
+			t.scripts[query] = script[1:]
+		}
+	}
+
+	delay := f.Delay + time.Duration(jitter*float64(f.Jitter))
+
+	return *f, delay, corruptAt
+}
+
+func statusResponse(req *http.Request, status int, retryAfter time.Duration) *http.Response {
+	body := []byte(http.StatusText(status) + "\n")
+	header := http.Header{"Content-Type": {"text/plain; charset=utf-8"}}
+
+	if retryAfter > 0 {
+		seconds := (retryAfter + time.Second - 1) / time.Second
+		header.Set("Retry-After", strconv.Itoa(int(seconds)))
+	}
+
+	return response(req, status, header, body)
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
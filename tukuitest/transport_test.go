@@ -0,0 +1,134 @@
+package tukuitest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/unly/go-tukui"
+)
+
+func newFaultClient(t *testing.T, seed int64) (*FaultTransport, *tukui.Client) {
+	server := NewServer()
+	t.Cleanup(server.Close)
+
+	addons := []tukui.Addon{{Id: String("3"), Name: String("AddOnSkins")}}
+	for _, flavor := range []tukui.Flavor{tukui.FlavorRetail, tukui.FlavorClassic} {
+		if err := server.SetAddons(flavor, addons...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	faults := WrapClient(&http.Client{Timeout: 5 * time.Second}, seed)
+	client := tukui.NewClient(faults.Client())
+	client.SetBaseURL(server.URL)
+
+	return faults, client
+}
+
+func TestFaultTransport_Inject(t *testing.T) {
+	faults, client := newFaultClient(t, 1)
+
+	faults.Inject("classic-addons=all",
+		TransportFault{Status: http.StatusTooManyRequests, RetryAfter: 1500 * time.Millisecond, Times: 1},
+		TransportFault{Reset: true, Times: 1},
+		TransportFault{Corrupt: true, Times: 1},
+	)
+
+	_, resp, err := client.ClassicAddons.GetAddons()
+	if err == nil || resp == nil || resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "2" {
+		t.Errorf("first GetAddons() returned %+v, %v, want 429 with Retry-After 2", resp, err)
+	}
+
+	if _, _, err := client.ClassicAddons.GetAddons(); !errors.Is(err, syscall.ECONNRESET) {
+		t.Errorf("second GetAddons() returned error %v, want %v", err, syscall.ECONNRESET)
+	}
+
+	if _, _, err := client.ClassicAddons.GetAddons(); err == nil {
+		t.Errorf("third GetAddons() with corrupted JSON returned no error")
+	}
+
+	if _, _, err := client.ClassicAddons.GetAddons(); err != nil {
+		t.Errorf("GetAddons() after the script returned error %v", err)
+	}
+
+	if _, _, err := client.RetailAddons.GetAddons(); err != nil {
+		t.Errorf("retail GetAddons() returned error %v, only classic should fail", err)
+	}
+
+	faults.Inject("", TransportFault{Status: http.StatusServiceUnavailable})
+	if _, resp, _ := client.RetailAddons.GetAddon(3); resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("GetAddon() returned %+v, want 503", resp)
+	}
+
+	faults.ClearFaults()
+	if _, _, err := client.RetailAddons.GetAddon(3); err != nil {
+		t.Errorf("GetAddon() after ClearFaults() returned error %v", err)
+	}
+}
+
+func TestFaultTransport_Truncate(t *testing.T) {
+	faults, client := newFaultClient(t, 1)
+	faults.Inject("addon=3", TransportFault{Truncate: true})
+
+	if _, _, err := client.RetailAddons.GetAddon(3); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("GetAddon() returned error %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestFaultTransport_Seed(t *testing.T) {
+	outcomes := func(seed int64) []bool {
+		faults, client := newFaultClient(t, seed)
+		faults.Inject("", TransportFault{Reset: true, Probability: 0.5})
+
+		var failed []bool
+		for n := 0; n < 20; n++ {
+			_, _, err := client.RetailAddons.GetAddon(3)
+			failed = append(failed, err != nil)
+		}
+		return failed
+	}
+
+	first, second := outcomes(7), outcomes(7)
+	if !cmp.Equal(first, second) {
+		t.Errorf("runs with the same seed failed %v and %v", first, second)
+	}
+
+	if other := outcomes(8); cmp.Equal(first, other) {
+		t.Errorf("runs with different seeds both failed %v", first)
+	}
+}
+
+func TestFaultTransport_Cancel(t *testing.T) {
+	faults := WrapClient(nil, 1)
+	faults.Inject("", TransportFault{Delay: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://127.0.0.1:1/api.php?addons=all", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	resp, err := faults.Client().Do(req)
+	if err == nil {
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do() returned error %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Do() with delay took %v", time.Since(start))
+	}
+}
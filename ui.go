@@ -0,0 +1,128 @@
+package tukui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownUI is returned by GetUI for UI suites that do not exist for a flavor.
+var ErrUnknownUI = errors.New("unknown ui suite")
+
+// UISuites are the names of the UI suites the api.php serves with its ui query.
+// They are not part of the retail catalog, so ListUIs of the retail client queries
+// them one by one. Suites of other names can still be fetched with GetUI.
+var UISuites = []string{"tukui", "elvui"}
+
+// uiCategories are the catalog categories of UI suites.
+var uiCategories = []string{"Interfaces", "Full UI Replacements"}
+
+// IsUI reports whether the addon is a UI suite. UI suites have a negative ID, like
+// the ones of the ui query, or are in the category of UI suites of the catalog.
+func IsUI(addon Addon) bool {
+	if addon.Id != nil && strings.HasPrefix(*addon.Id, "-") {
+		return true
+	}
+
+	for _, category := range uiCategories {
+		if addon.Category != nil && strings.EqualFold(*addon.Category, category) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FetchCatalog returns the addons of the client together with its UI suites, fetching
+// the catalog only once. The catalogs of the classic and the v1 API include the UI
+// suites, the UISuites of the retail api.php are added with the ui query. For other
+// AddonClients the UI suites are taken from ListUIs.
+func FetchCatalog(ctx context.Context, client AddonClient) ([]Addon, error) {
+	addons, _, err := client.GetAddons()
+	if err != nil {
+		return nil, err
+	}
+
+	var uis []Addon
+	switch c := client.(type) {
+	case *classicClient, *v1Client:
+		return addons, nil
+	case *retailClient:
+		uis, err = c.addUISuites(ctx, nil)
+	default:
+		uis, _, err = client.ListUIs(ctx)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, addon := range addons {
+		if addon.Id != nil {
+			seen[*addon.Id] = true
+		}
+	}
+
+	for _, ui := range uis {
+		if ui.Id == nil || !seen[*ui.Id] {
+			addons = append(addons, ui)
+		}
+	}
+
+	return addons, nil
+}
+
+func filterUIs(addons []Addon) []Addon {
+	uis := make([]Addon, 0)
+	for _, addon := range addons {
+		if IsUI(addon) {
+			uis = append(uis, addon)
+		}
+	}
+
+	return uis
+}
+
+// findUI returns the UI suite of the catalog with the given name, compared like the
+// names of FindBySlug. Catalogs do not always categorize the UISuites, so for their
+// names an addon of any category matches if there is no UI suite of the name.
+func findUI(addons []Addon, name string, flavor Flavor) (Addon, error) {
+	want := normalizeName(name)
+	if want == "" {
+		return Addon{}, unknownUI(name, flavor)
+	}
+
+	known := false
+	for _, suite := range UISuites {
+		if suite == want {
+			known = true
+		}
+	}
+
+	match := -1
+	for i, addon := range addons {
+		if addon.Name == nil || normalizeName(*addon.Name) != want {
+			continue
+		}
+
+		if IsUI(addon) {
+			return addon, nil
+		}
+
+		if known && match < 0 {
+			match = i
+		}
+	}
+
+	if match < 0 {
+		return Addon{}, unknownUI(name, flavor)
+	}
+
+	return addons[match], nil
+}
+
+func unknownUI(name string, flavor Flavor) error {
+	return fmt.Errorf("%w %q for %s", ErrUnknownUI, name, flavor)
+}
@@ -0,0 +1,70 @@
+package tukui
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIsUI(t *testing.T) {
+	tests := []struct {
+		addon Addon
+		want  bool
+	}{
+		{Addon{Id: String("-2"), Name: String("ElvUI")}, true},
+		{Addon{Id: String("1"), Category: String("Interfaces")}, true},
+		{Addon{Id: String("9"), Category: String("full ui replacements")}, true},
+		{Addon{Id: String("3"), Category: String("Skins")}, false},
+		{Addon{}, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsUI(tt.addon); got != tt.want {
+			t.Errorf("IsUI(%+v) returned %v, want %v", tt.addon, got, tt.want)
+		}
+	}
+}
+
+func TestFindUI(t *testing.T) {
+	addons := []Addon{
+		{Id: String("7"), Name: String("ElvUI"), Category: String("Plugins: ElvUI")},
+		{Id: String("2"), Name: String("ElvUI"), Category: String("Interfaces")},
+		{Id: String("5"), Name: String("Shadow & Light"), Category: String("Full UI Replacements")},
+		{Id: String("1"), Name: String("Tukui")},
+		{Id: String("3"), Name: String("AddOnSkins"), Category: String("Skins")},
+	}
+
+	tests := []struct {
+		name string
+		want Addon
+	}{
+		{"elvui", addons[1]},
+		{"shadow-light", addons[2]},
+		// known suites match without a UI category
+		{"tukui", addons[3]},
+	}
+
+	for _, tt := range tests {
+		got, err := findUI(addons, tt.name, FlavorClassic)
+		if err != nil {
+			t.Errorf("findUI(%q) returned error: %v", tt.name, err)
+		}
+
+		if !cmp.Equal(got, tt.want) {
+			t.Errorf("findUI(%q) returned %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	for _, name := range []string{"AddOnSkins", "gw2", ""} {
+		_, err := findUI(addons, name, FlavorClassic)
+		if !errors.Is(err, ErrUnknownUI) {
+			t.Errorf("findUI(%q) returned error %v, want %v", name, err, ErrUnknownUI)
+		}
+	}
+
+	want := `unknown ui suite "AddOnSkins" for classic`
+	if _, err := findUI(addons, "AddOnSkins", FlavorClassic); err.Error() != want {
+		t.Errorf("findUI() returned error %q, want %q", err, want)
+	}
+}
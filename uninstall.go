@@ -0,0 +1,164 @@
+package tukui
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotInstalled is returned for addons that are not recorded in the manifest.
+var ErrNotInstalled = errors.New("addon is not installed")
+
+// UninstallOptions changes the behavior of Installer.Uninstall.
+type UninstallOptions struct {
+	// DryRun only reports what would be removed without changing anything
+	DryRun bool
+}
+
+// UninstallResult is returned by Installer.Uninstall.
+type UninstallResult struct {
+	// Removed are the folders deleted from the AddOns directory
+	Removed []string
+	// Kept are the folders still owned by another installed addon
+	Kept []string
+	// Missing are the folders recorded in the manifest that did not exist anymore
+	Missing []string
+}
+
+// Uninstall removes the addon with the given ID. Only the folders recorded in the
+// manifest are deleted, folders another installed addon still owns are kept. The
+// folders are moved out of the AddOns directory before the manifest entry is removed,
+// so a failure leaves both the folders and the manifest untouched. If the process
+// crashes in between, the next installation restores the folders as long as the
+// manifest still lists the addon.
+func (i *Installer) Uninstall(id string, opts *UninstallOptions) (UninstallResult, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var result UninstallResult
+
+	manifest, err := i.Manifest()
+	if err != nil {
+		return result, err
+	}
+
+	entry, ok := manifest.Entry(id)
+	if !ok {
+		return result, fmt.Errorf("%w: %s", ErrNotInstalled, id)
+	}
+
+	owned := make(map[string]bool)
+	for _, other := range manifest.Addons {
+		if other.ID == id {
+			continue
+		}
+		for _, folder := range other.Folders {
+			owned[strings.ToLower(folder)] = true
+		}
+	}
+
+	for _, folder := range entry.Folders {
+		if !isFolderName(folder) {
+			return UninstallResult{}, fmt.Errorf("%w: %s", ErrUnsafePath, folder)
+		}
+
+		switch _, err := os.Lstat(filepath.Join(i.addonsDir, folder)); {
+		case owned[strings.ToLower(folder)]:
+			result.Kept = append(result.Kept, folder)
+		case os.IsNotExist(err):
+			result.Missing = append(result.Missing, folder)
+		case err != nil:
+			return UninstallResult{}, err
+		default:
+			result.Removed = append(result.Removed, folder)
+		}
+	}
+
+	if opts != nil && opts.DryRun {
+		return result, nil
+	}
+
+	unlock := lockAddonsDir(i.addonsDir)
+	defer unlock()
+
+	trash, err := ioutil.TempDir(i.addonsDir, removedPrefix)
+	if err != nil {
+		return UninstallResult{}, err
+	}
+
+	if err := writeJournal(trash, removal{ID: id, Folders: result.Removed}); err != nil {
+		os.RemoveAll(trash)
+		return UninstallResult{}, err
+	}
+
+	var moved []string
+	restore := func() {
+		for _, folder := range moved {
+			os.Rename(filepath.Join(trash, folder), filepath.Join(i.addonsDir, folder))
+		}
+		os.RemoveAll(trash)
+	}
+
+	for _, folder := range result.Removed {
+		if err := os.Rename(filepath.Join(i.addonsDir, folder), filepath.Join(trash, folder)); err != nil {
+			restore()
+			return UninstallResult{}, err
+		}
+		moved = append(moved, folder)
+	}
+
+	manifest.remove(id)
+	if err := manifest.Write(i.ManifestPath()); err != nil {
+		restore()
+		return UninstallResult{}, err
+	}
+
+	// the manifest does not list the addon anymore, so an interrupted removal of the
+	// trash is finished by recoverRemoval
+	os.RemoveAll(trash)
+
+	return result, nil
+}
+
+// removal is the journal of an uninstallation. It lists the folders moved out of
+// the AddOns directory for the addon with the ID.
+type removal struct {
+	ID      string   `json:"id"`
+	Folders []string `json:"folders"`
+}
+
+// recoverRemoval finishes an interrupted uninstallation if the manifest next to
+// the AddOns directory does not list the addon anymore. Otherwise the folders in
+// the removed directory are moved back, so they match the manifest again.
+func recoverRemoval(addonsDir, removed string) {
+	var r removal
+	if err := readJournal(removed, &r); err == nil {
+		manifest, err := ReadManifest(manifestPath(addonsDir))
+		if _, ok := manifest.Entry(r.ID); err == nil && !ok {
+			os.RemoveAll(removed)
+			return
+		}
+	}
+
+	folders, _ := ioutil.ReadDir(removed)
+	for _, folder := range folders {
+		dest := filepath.Join(addonsDir, folder.Name())
+		if folder.Name() == journalName || !isFolderName(folder.Name()) {
+			continue
+		}
+
+		if _, err := os.Lstat(dest); os.IsNotExist(err) {
+			os.Rename(filepath.Join(removed, folder.Name()), dest)
+		}
+	}
+
+	os.RemoveAll(removed)
+}
+
+// isFolderName reports whether name is a plain folder name without any path elements.
+func isFolderName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\:`)
+}
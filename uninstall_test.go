@@ -0,0 +1,165 @@
+package tukui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// setupInstalled creates an Installer whose manifest records the given entries
+// and an AddOns directory with the given files.
+func setupInstalled(t *testing.T, entries []ManifestEntry, files map[string]string) *Installer {
+	t.Helper()
+	installer := NewInstaller(NewClient(nil), FlavorRetail, filepath.Join(tempDir(t), "Interface", "AddOns"))
+	if err := os.MkdirAll(installer.addonsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeAddOns(t, installer.addonsDir, files)
+
+	manifest := &Manifest{Addons: entries}
+	if err := manifest.Write(installer.ManifestPath()); err != nil {
+		t.Fatal(err)
+	}
+
+	return installer
+}
+
+func TestInstaller_Uninstall(t *testing.T) {
+	installer := setupInstalled(t,
+		[]ManifestEntry{
+			{ID: "-2", Name: "ElvUI", Folders: []string{"ElvUI", "ElvUI_Libraries", "ElvUI_Options"}},
+			{ID: "3", Name: "AddOnSkins", Folders: []string{"AddOnSkins", "ElvUI_Libraries", "Gone"}},
+		},
+		map[string]string{
+			"ElvUI/ElvUI.toc":                     "",
+			"ElvUI_Libraries/ElvUI_Libraries.toc": "",
+			"ElvUI_Options/ElvUI_Options.toc":     "",
+			"AddOnSkins/AddOnSkins.toc":           "",
+			"Details/Details.toc":                 "",
+		},
+	)
+
+	result, err := installer.Uninstall("3", nil)
+	if err != nil {
+		t.Fatalf("Installer.Uninstall() returned error: %v", err)
+	}
+
+	want := UninstallResult{
+		Removed: []string{"AddOnSkins"},
+		Kept:    []string{"ElvUI_Libraries"},
+		Missing: []string{"Gone"},
+	}
+	if !cmp.Equal(result, want) {
+		t.Errorf("Installer.Uninstall() returned %+v, want %+v", result, want)
+	}
+
+	wantFiles := []string{
+		"Details/Details.toc",
+		"ElvUI/ElvUI.toc",
+		"ElvUI_Libraries/ElvUI_Libraries.toc",
+		"ElvUI_Options/ElvUI_Options.toc",
+	}
+	if got := listFiles(t, installer.addonsDir); !cmp.Equal(got, wantFiles) {
+		t.Errorf("Installer.Uninstall() left %v, want %v", got, wantFiles)
+	}
+
+	manifest, err := installer.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := manifest.Entry("3"); ok || len(manifest.Addons) != 1 {
+		t.Errorf("Installer.Uninstall() left manifest %+v", manifest.Addons)
+	}
+}
+
+func TestInstaller_Uninstall_DryRun(t *testing.T) {
+	installer := setupInstalled(t,
+		[]ManifestEntry{{ID: "-2", Folders: []string{"ElvUI", "ElvUI_Options"}}},
+		map[string]string{
+			"ElvUI/ElvUI.toc":                 "",
+			"ElvUI_Options/ElvUI_Options.toc": "",
+		},
+	)
+
+	result, err := installer.Uninstall("-2", &UninstallOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Installer.Uninstall() returned error: %v", err)
+	}
+
+	want := UninstallResult{Removed: []string{"ElvUI", "ElvUI_Options"}}
+	if !cmp.Equal(result, want) {
+		t.Errorf("Installer.Uninstall() returned %+v, want %+v", result, want)
+	}
+
+	if got := listFiles(t, installer.addonsDir); len(got) != 2 {
+		t.Errorf("Installer.Uninstall() with DryRun left %v", got)
+	}
+
+	manifest, err := installer.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := manifest.Entry("-2"); !ok {
+		t.Errorf("Installer.Uninstall() with DryRun removed the manifest entry")
+	}
+}
+
+func TestInstaller_Uninstall_Errors(t *testing.T) {
+	installer := setupInstalled(t,
+		[]ManifestEntry{{ID: "6", Folders: []string{"ElvUI_LocPlus", "../Fonts"}}},
+		map[string]string{"ElvUI_LocPlus/ElvUI_LocPlus.toc": ""},
+	)
+
+	if _, err := installer.Uninstall("3", nil); !errors.Is(err, ErrNotInstalled) {
+		t.Errorf("Installer.Uninstall() returned %v, want %v", err, ErrNotInstalled)
+	}
+
+	if _, err := installer.Uninstall("6", nil); !errors.Is(err, ErrUnsafePath) {
+		t.Errorf("Installer.Uninstall() returned %v, want %v", err, ErrUnsafePath)
+	}
+
+	if got := listFiles(t, installer.addonsDir); len(got) != 1 {
+		t.Errorf("Installer.Uninstall() left %v, want the addon untouched", got)
+	}
+}
+
+func TestInstaller_Uninstall_Interrupted(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed bool
+		want      []string
+	}{
+		{"before manifest update", true, []string{"AddOnSkins/AddOnSkins.toc", "ElvUI/ElvUI.toc"}},
+		{"after manifest update", false, []string{"ElvUI/ElvUI.toc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries []ManifestEntry
+			if tt.installed {
+				entries = append(entries, ManifestEntry{ID: "3", Name: "AddOnSkins", Folders: []string{"AddOnSkins"}})
+			}
+
+			// the folder was moved out of the AddOns directory before the crash
+			trash := removedPrefix + "123"
+			installer := setupInstalled(t, entries, map[string]string{trash + "/AddOnSkins/AddOnSkins.toc": ""})
+			if err := writeJournal(filepath.Join(installer.addonsDir, trash), removal{ID: "3", Folders: []string{"AddOnSkins"}}); err != nil {
+				t.Fatal(err)
+			}
+
+			zipPath := writeZip(t, tempDir(t), zipEntry{name: "ElvUI/ElvUI.toc"})
+			if _, err := InstallZip(zipPath, installer.addonsDir, nil); err != nil {
+				t.Fatalf("InstallZip() returned error: %v", err)
+			}
+
+			if got := listFiles(t, installer.addonsDir); !cmp.Equal(got, tt.want) {
+				t.Errorf("InstallZip() left %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package tukui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/unly/go-tukui/version"
+)
+
+// ErrUnsupportedFlavor is returned for flavors the TukUI API has no addons for.
+var ErrUnsupportedFlavor = errors.New("flavor not supported by the api")
+
+// UpdateStatus compares an installed version with the version of the catalog.
+type UpdateStatus int
+
+const (
+	// StatusUnknown means the versions could not be compared
+	StatusUnknown UpdateStatus = iota
+	// StatusUpToDate means the installed version is the catalog version
+	StatusUpToDate
+	// StatusOutdated means the catalog has a newer version
+	StatusOutdated
+	// StatusAhead means the installed version is newer than the catalog version
+	StatusAhead
+)
+
+func (s UpdateStatus) String() string {
+	switch s {
+	case StatusUpToDate:
+		return "up to date"
+	case StatusOutdated:
+		return "outdated"
+	case StatusAhead:
+		return "ahead"
+	}
+
+	return "unknown"
+}
+
+// An Update describes the update state of an installed addon.
+type Update struct {
+	// Installed is the installed addon
+	Installed InstalledAddon
+	// Addon is the current catalog entry, nil if the addon is not in the catalog
+	Addon *Addon
+	// LocalVersion is the version of the installed addon
+	LocalVersion string
+	// RemoteVersion is the version of the catalog entry
+	RemoteVersion string
+	// LastUpdate is the time the catalog entry was last updated
+	LastUpdate string
+	// Status of the installed version
+	Status UpdateStatus
+}
+
+// AddonsFor returns the AddonClient for the addons of the given flavor. The api.php
+// only has catalogs for retail and Classic Era, the v1 API supports all flavors.
+func (c *Client) AddonsFor(flavor Flavor) (AddonClient, error) {
+	switch flavor {
+	case FlavorRetail:
+		return c.RetailAddons, nil
+	case FlavorClassic:
+		return c.ClassicAddons, nil
+	}
+
+	if c.version == APIV1 {
+		for _, f := range Flavors {
+			if f == flavor {
+				return newV1Client(c, flavor), nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedFlavor, flavor)
+}
+
+// CheckUpdates compares the installed addons, as matched by Scan or MatchAddons, with
+// the current catalog of the given flavor. The catalog is fetched once per call and
+// the UISuites are looked up in it, only the retail api.php serves them with the ui
+// query instead. Addons without a catalog match, like UI suites that do not exist
+// for the flavor, are returned with StatusUnknown.
+//
+// CheckUpdates does not modify the Client, it can be called concurrently for
+// different flavors.
+func (c *Client) CheckUpdates(installed []InstalledAddon, flavor Flavor) ([]Update, error) {
+	addons, err := c.AddonsFor(flavor)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := newRemoteCatalog(addons, flavor)
+	updates := make([]Update, 0, len(installed))
+
+	for _, addon := range installed {
+		update := Update{
+			Installed:    addon,
+			LocalVersion: addon.Version(),
+		}
+
+		if addon.Addon != nil {
+			remote, err := catalog.lookup(*addon.Addon)
+			if err != nil {
+				return nil, err
+			}
+			update.Addon = remote
+		}
+
+		if update.Addon != nil {
+			update.RemoteVersion = stringValue(update.Addon.Version)
+			update.LastUpdate = stringValue(update.Addon.LastUpdate)
+			update.Status = compareStatus(update.LocalVersion, update.RemoteVersion)
+		}
+
+		updates = append(updates, update)
+	}
+
+	return updates, nil
+}
+
+// remoteCatalog fetches the catalog of an AddonClient lazily and at most once.
+type remoteCatalog struct {
+	client AddonClient
+	flavor Flavor
+	list   []Addon
+	addons map[string]Addon
+	uis    map[string]*Addon
+}
+
+func newRemoteCatalog(client AddonClient, flavor Flavor) *remoteCatalog {
+	return &remoteCatalog{
+		client: client,
+		flavor: flavor,
+		uis:    make(map[string]*Addon),
+	}
+}
+
+// lookup returns the current catalog entry for the given addon, or nil if it does
+// not exist anymore.
+func (r *remoteCatalog) lookup(addon Addon) (*Addon, error) {
+	if r.addons == nil {
+		list, _, err := r.client.GetAddons()
+		if err != nil {
+			return nil, err
+		}
+
+		r.list = list
+		r.addons = make(map[string]Addon, len(list))
+		for _, a := range list {
+			if a.Id != nil {
+				r.addons[*a.Id] = a
+			}
+		}
+	}
+
+	if name := uiSuite(addon); name != "" {
+		return r.lookupUI(name)
+	}
+
+	if addon.Id == nil {
+		return nil, nil
+	}
+
+	remote, ok := r.addons[*addon.Id]
+	if !ok {
+		return nil, nil
+	}
+
+	return &remote, nil
+}
+
+// lookupUI returns the UI suite with the name, or nil if it does not exist for the
+// flavor. The catalogs of the classic and the v1 API include the UI suites, the
+// retail api.php serves them with the ui query. Other AddonClients use GetUI.
+func (r *remoteCatalog) lookupUI(name string) (*Addon, error) {
+	if ui, ok := r.uis[name]; ok {
+		return ui, nil
+	}
+
+	var ui Addon
+	var err error
+
+	switch c := r.client.(type) {
+	case *retailClient:
+		ui, _, err = c.getUI(context.Background(), name)
+		if errors.Is(err, ErrEmptyResponse) {
+			ui, err = findUI(r.list, name, r.flavor)
+		}
+	case *classicClient, *v1Client:
+		ui, err = findUI(r.list, name, r.flavor)
+	default:
+		ui, _, err = c.GetUI(context.Background(), name)
+	}
+
+	switch {
+	case errors.Is(err, ErrUnknownUI):
+		r.uis[name] = nil
+	case err != nil:
+		return nil, err
+	default:
+		r.uis[name] = &ui
+	}
+
+	return r.uis[name], nil
+}
+
+// uiSuite returns "tukui" or "elvui" if the addon is one of the UI suites.
+func uiSuite(addon Addon) string {
+	if addon.Name == nil {
+		return ""
+	}
+
+	name := normalizeName(*addon.Name)
+	for _, suite := range UISuites {
+		if name == suite {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// compareStatus compares two version strings with the version package. Versions
+// that cannot be parsed are only considered up to date if they are equal.
+func compareStatus(local, remote string) UpdateStatus {
+	c, err := version.CompareStrings(local, remote)
+	if err != nil {
+		if local != "" && local == remote {
+			return StatusUpToDate
+		}
+		return StatusUnknown
+	}
+
+	switch {
+	case c < 0:
+		return StatusOutdated
+	case c > 0:
+		return StatusAhead
+	}
+
+	return StatusUpToDate
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
@@ -0,0 +1,151 @@
+package tukui
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func installedAddon(folder, version string, addon *Addon) InstalledAddon {
+	return InstalledAddon{
+		Folders:    []InstalledFolder{{Name: folder, TOC: &TOC{Name: folder, Version: version}}},
+		Addon:      addon,
+		Confidence: MatchTitle,
+	}
+}
+
+func catalogHandler(t *testing.T, requests map[string]int, mu *sync.Mutex) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.RawQuery]++
+		mu.Unlock()
+
+		switch r.URL.RawQuery {
+		case "addons=all":
+			fmt.Fprint(w, `[
+				{"id": "3", "name": "AddOnSkins", "version": "3.53", "lastupdate": "2020-09-09"},
+				{"id": "6", "name": "LocationPlus for ElvUI", "version": "2.48", "lastupdate": "2020-08-01"},
+				{"id": "7", "name": "Weird", "version": "beta", "lastupdate": "2020-08-01"}
+			]`)
+		case "ui=elvui":
+			fmt.Fprint(w, `{"id": -2, "name": "ElvUI", "version": "11.52", "lastupdate": "2020-09-20"}`)
+		case "classic-addons=all":
+			fmt.Fprint(w, `[
+				{"id": "2", "name": "ElvUI", "version": "1.31", "lastupdate": "2020-09-07"},
+				{"id": "3", "name": "AddOnSkins", "version": "1.10", "lastupdate": "2020-09-01"}
+			]`)
+		default:
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+	}
+}
+
+func TestClient_CheckUpdates(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	mux.HandleFunc("/", catalogHandler(t, requests, &mu))
+
+	installed := []InstalledAddon{
+		installedAddon("AddOnSkins", "3.53", &Addon{Id: String("3"), Name: String("AddOnSkins")}),
+		installedAddon("ElvUI", "v11.50", &Addon{Id: String("-2"), Name: String("ElvUI")}),
+		installedAddon("ElvUI_LocPlus", "2.50", &Addon{Id: String("6"), Name: String("LocationPlus for ElvUI")}),
+		installedAddon("Weird", "alpha", &Addon{Id: String("7"), Name: String("Weird")}),
+		installedAddon("Removed", "1.0", &Addon{Id: String("99"), Name: String("Removed")}),
+		installedAddon("Details", "1.0", nil),
+	}
+
+	updates, err := client.CheckUpdates(installed, FlavorRetail)
+	if err != nil {
+		t.Fatalf("Client.CheckUpdates() returned error: %v", err)
+	}
+
+	type result struct {
+		Local, Remote, LastUpdate string
+		Status                    UpdateStatus
+	}
+
+	var got []result
+	for _, u := range updates {
+		got = append(got, result{u.LocalVersion, u.RemoteVersion, u.LastUpdate, u.Status})
+	}
+
+	want := []result{
+		{"3.53", "3.53", "2020-09-09", StatusUpToDate},
+		{"v11.50", "11.52", "2020-09-20", StatusOutdated},
+		{"2.50", "2.48", "2020-08-01", StatusAhead},
+		{"alpha", "beta", "2020-08-01", StatusUnknown},
+		{"1.0", "", "", StatusUnknown},
+		{"1.0", "", "", StatusUnknown},
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("Client.CheckUpdates() returned %+v, want %+v", got, want)
+	}
+
+	wantRequests := map[string]int{"addons=all": 1, "ui=elvui": 1}
+	if !cmp.Equal(requests, wantRequests) {
+		t.Errorf("Client.CheckUpdates() made requests %v, want %v", requests, wantRequests)
+	}
+}
+
+func TestClient_CheckUpdates_Concurrent(t *testing.T) {
+	client, mux, teardown := setupTestEnv()
+	defer teardown()
+
+	var mu sync.Mutex
+	mux.HandleFunc("/", catalogHandler(t, make(map[string]int), &mu))
+
+	installed := []InstalledAddon{
+		installedAddon("AddOnSkins", "1.10", &Addon{Id: String("3"), Name: String("AddOnSkins")}),
+		installedAddon("ElvUI", "1.31", &Addon{Id: String("2"), Name: String("ElvUI")}),
+	}
+
+	var wg sync.WaitGroup
+	results := make(map[Flavor][]Update)
+
+	for _, flavor := range []Flavor{FlavorRetail, FlavorClassic} {
+		wg.Add(1)
+		go func(flavor Flavor) {
+			defer wg.Done()
+			updates, err := client.CheckUpdates(installed, flavor)
+			if err != nil {
+				t.Errorf("Client.CheckUpdates(%s) returned error: %v", flavor, err)
+			}
+
+			mu.Lock()
+			results[flavor] = updates
+			mu.Unlock()
+		}(flavor)
+	}
+
+	wg.Wait()
+
+	for flavor, want := range map[Flavor][]UpdateStatus{
+		FlavorRetail:  {StatusOutdated, StatusOutdated},
+		FlavorClassic: {StatusUpToDate, StatusUpToDate},
+	} {
+		var got []UpdateStatus
+		for _, u := range results[flavor] {
+			got = append(got, u.Status)
+		}
+
+		if !cmp.Equal(got, want) {
+			t.Errorf("Client.CheckUpdates(%s) returned %v, want %v", flavor, got, want)
+		}
+	}
+}
+
+func TestClient_CheckUpdates_UnsupportedFlavor(t *testing.T) {
+	client := NewClient(nil)
+
+	_, err := client.CheckUpdates(nil, FlavorWrath)
+	if !errors.Is(err, ErrUnsupportedFlavor) {
+		t.Errorf("Client.CheckUpdates() returned %v, want %v", err, ErrUnsupportedFlavor)
+	}
+}
@@ -0,0 +1,242 @@
+// Package version parses and orders the version strings of addons hosted on tukui.org.
+//
+// Addon authors use many different schemes, like 3.53, 12.10, v1.2.3-beta2, 1.0a or
+// r42. Parse accepts all of them and Compare orders them the way authors intend:
+// numbers are compared numerically, so 12.10 is newer than 12.9, pre-releases come
+// before their release and a letter suffix like 1.0a marks a hotfix after 1.0.
+package version
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalid is returned for version strings that cannot be parsed.
+var ErrInvalid = errors.New("invalid version")
+
+// pre-release stages in ascending order
+const (
+	stageDev = iota + 1
+	stageAlpha
+	stageBeta
+	stagePreview
+	stageRC
+)
+
+// stages maps the pre-release tags to their stage
+var stages = map[string]int{
+	"dev":     stageDev,
+	"alpha":   stageAlpha,
+	"a":       stageAlpha,
+	"beta":    stageBeta,
+	"b":       stageBeta,
+	"pre":     stagePreview,
+	"preview": stagePreview,
+	"rc":      stageRC,
+}
+
+// A Version is a parsed addon version. The zero value is not a valid Version.
+type Version struct {
+	original string
+	// release numbers, e.g. 1, 2 and 3 for 1.2.3 or 42 for r42
+	numbers []int
+	// revision is true for versions like r42
+	revision bool
+	// hotfix letter of versions like 1.0a
+	hotfix string
+	// pre-release stage and number, e.g. stageBeta and 2 for -beta2
+	stage    int
+	stageNum int
+}
+
+// Parse parses a version string. A leading v and build metadata after a + are
+// ignored. It returns an error wrapping ErrInvalid if s is not a known version format.
+func Parse(s string) (Version, error) {
+	v := Version{original: strings.TrimSpace(s)}
+
+	rest := strings.ToLower(v.original)
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		rest = rest[:i]
+	}
+
+	switch {
+	case len(rest) > 1 && rest[0] == 'r' && isDigit(rest[1]):
+		v.revision = true
+		rest = rest[1:]
+	case len(rest) > 1 && rest[0] == 'v' && isDigit(rest[1]):
+		rest = rest[1:]
+	}
+
+	for {
+		n := digits(rest)
+		if n == 0 {
+			return Version{}, fmt.Errorf("%w: %q", ErrInvalid, s)
+		}
+
+		number, err := strconv.Atoi(rest[:n])
+		if err != nil {
+			return Version{}, fmt.Errorf("%w: %q", ErrInvalid, s)
+		}
+
+		v.numbers = append(v.numbers, number)
+		rest = rest[n:]
+
+		if v.revision || !strings.HasPrefix(rest, ".") || len(rest) < 2 || !isDigit(rest[1]) {
+			break
+		}
+		rest = rest[1:]
+	}
+
+	if rest == "" {
+		return v, nil
+	}
+
+	// a single letter right after the numbers is a hotfix, like 1.0a
+	if !v.revision && len(rest) == 1 && rest[0] >= 'a' && rest[0] <= 'z' {
+		v.hotfix = rest
+		return v, nil
+	}
+
+	if !v.revision && v.parsePrerelease(rest) {
+		return v, nil
+	}
+
+	return Version{}, fmt.Errorf("%w: %q", ErrInvalid, s)
+}
+
+// parsePrerelease parses suffixes like -beta2, .rc.1, alpha or b3.
+func (v *Version) parsePrerelease(s string) bool {
+	s = strings.TrimLeft(s, "-_. ")
+
+	n := 0
+	for n < len(s) && s[n] >= 'a' && s[n] <= 'z' {
+		n++
+	}
+
+	stage, ok := stages[s[:n]]
+	if !ok {
+		return false
+	}
+
+	// single letters need a number to be a pre-release, otherwise they are a hotfix
+	num := strings.TrimLeft(s[n:], "-_.")
+	if n == 1 && num == "" {
+		return false
+	}
+
+	if num != "" {
+		if digits(num) != len(num) {
+			return false
+		}
+
+		var err error
+		if v.stageNum, err = strconv.Atoi(num); err != nil {
+			return false
+		}
+	}
+
+	v.stage = stage
+
+	return true
+}
+
+// MustParse is like Parse but panics if s cannot be parsed.
+func MustParse(s string) Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+// String returns the version string v was parsed from.
+func (v Version) String() string {
+	return v.original
+}
+
+// IsPrerelease reports whether v is a development, alpha, beta or release candidate version.
+func (v Version) IsPrerelease() bool {
+	return v.stage != 0
+}
+
+// Compare returns -1 if a is older than b, 1 if a is newer than b and 0 if both
+// are the same version.
+func Compare(a, b Version) int {
+	for i := 0; i < len(a.numbers) || i < len(b.numbers); i++ {
+		if c := compareInt(at(a.numbers, i), at(b.numbers, i)); c != 0 {
+			return c
+		}
+	}
+
+	// a release is newer than its pre-releases
+	if a.stage != b.stage {
+		if a.stage == 0 {
+			return 1
+		}
+		if b.stage == 0 {
+			return -1
+		}
+		return compareInt(a.stage, b.stage)
+	}
+
+	if c := compareInt(a.stageNum, b.stageNum); c != 0 {
+		return c
+	}
+
+	return strings.Compare(a.hotfix, b.hotfix)
+}
+
+// CompareStrings parses and compares two version strings like Compare.
+func CompareStrings(a, b string) (int, error) {
+	va, err := Parse(a)
+	if err != nil {
+		return 0, err
+	}
+
+	vb, err := Parse(b)
+	if err != nil {
+		return 0, err
+	}
+
+	return Compare(va, vb), nil
+}
+
+// Less reports whether v is older than o.
+func (v Version) Less(o Version) bool {
+	return Compare(v, o) < 0
+}
+
+func at(numbers []int, i int) int {
+	if i < len(numbers) {
+		return numbers[i]
+	}
+
+	return 0
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+func digits(s string) int {
+	n := 0
+	for n < len(s) && isDigit(s[n]) {
+		n++
+	}
+
+	return n
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,131 @@
+package version
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse_Invalid(t *testing.T) {
+	for _, s := range []string{"", "v", "beta", "1.", "1..2", "1.0-b", "1.0 final", "r42b", "1.2.3-beta2x", "latest"} {
+		if _, err := Parse(s); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q) returned %v, want %v", s, err, ErrInvalid)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"3.53", "3.53", 0},
+		{"3.53", "v3.53", 0},
+		{"3.53", "3.53.0", 0},
+		{"12.9", "12.10", -1},
+		{"2.48", "18.28", -1},
+		{"1.2.3-beta2", "1.2.3", -1},
+		{"1.2.3-beta2", "1.2.3-beta10", -1},
+		{"1.2.3-alpha", "1.2.3-beta", -1},
+		{"1.2.3-rc1", "1.2.3-beta5", 1},
+		{"1.2.3-dev", "1.2.3-alpha1", -1},
+		{"1.0", "1.0a", -1},
+		{"1.0a", "1.0b", -1},
+		{"1.0a", "1.1", -1},
+		{"1.0b2", "1.0", -1},
+		{"r41", "r42", -1},
+		{"V13.64", "v13.64", 0},
+		{"1.0+build.5", "1.0", 0},
+	}
+
+	for _, tt := range tests {
+		got, err := CompareStrings(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("CompareStrings(%q, %q) returned error: %v", tt.a, tt.b, err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("CompareStrings(%q, %q) returned %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+
+		if back, _ := CompareStrings(tt.b, tt.a); back != -tt.want {
+			t.Errorf("CompareStrings(%q, %q) returned %d, want %d", tt.b, tt.a, back, -tt.want)
+		}
+	}
+}
+
+func TestVersion_Less(t *testing.T) {
+	versions := []string{"12.10", "1.0a", "12.9", "1.0", "1.0-beta1", "v1.2.3", "1.0-rc1"}
+	sort.Slice(versions, func(i, j int) bool {
+		return MustParse(versions[i]).Less(MustParse(versions[j]))
+	})
+
+	want := []string{"1.0-beta1", "1.0-rc1", "1.0", "1.0a", "v1.2.3", "12.9", "12.10"}
+	if !cmp.Equal(versions, want) {
+		t.Errorf("sorted versions %v, want %v", versions, want)
+	}
+}
+
+func TestVersion_IsPrerelease(t *testing.T) {
+	tests := map[string]bool{
+		"3.53":         false,
+		"1.0a":         false,
+		"r42":          false,
+		"v1.2.3-beta2": true,
+		"2.0.rc.1":     true,
+		"1.0 alpha":    true,
+		"4.0-dev":      true,
+	}
+
+	for s, want := range tests {
+		if got := MustParse(s).IsPrerelease(); got != want {
+			t.Errorf("Parse(%q).IsPrerelease() returned %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestVersion_String(t *testing.T) {
+	if got := MustParse(" v1.2.3-beta2 ").String(); got != "v1.2.3-beta2" {
+		t.Errorf("Version.String() returned %q, want %q", got, "v1.2.3-beta2")
+	}
+}
+
+func TestMustParse_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("MustParse() did not panic")
+		}
+	}()
+
+	MustParse("latest")
+}
+
+// FuzzParse checks that parsing never panics and that parsed versions are stable.
+// The corpus in testdata/fuzz/FuzzParse holds the versions of the catalog fixtures.
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{"3.53", "12.10", "v1.2.3-beta2", "1.0a", "r42"} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		v, err := Parse(s)
+		if err != nil {
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("Parse(%q) returned %v, want %v", s, err, ErrInvalid)
+			}
+			return
+		}
+
+		again, err := Parse(v.String())
+		if err != nil {
+			t.Fatalf("Parse(%q) of parsed version returned error: %v", v.String(), err)
+		}
+
+		if Compare(v, again) != 0 || Compare(v, v) != 0 {
+			t.Errorf("Parse(%q) is not stable", s)
+		}
+	})
+}